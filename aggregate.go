@@ -0,0 +1,244 @@
+package jsons
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The aggregates below read the value at keys within each element, like the
+// field of GroupBy, and skip elements where it is not a Number. They compute
+// with exact decimals, so the sum of 0.1 and 0.2 is 0.3. All but Sum return
+// null when there is nothing to aggregate.
+
+// avgDigits is the number of decimal places kept for results that do not
+// terminate, such as the average of 1 and 2 and 2.
+const avgDigits = 34
+
+// rats returns the Numbers at keys within the elements with their values.
+func (a Array) rats(keys ...interface{}) ([]Number, []*big.Rat) {
+	var nums []Number
+	var rats []*big.Rat
+	for _, elem := range a {
+		src, err := normalize(value(elem).Get(keys...).value)
+		if err != nil {
+			continue
+		}
+		if n, ok := src.(Number); ok {
+			if r, err := n.Rat(); err == nil {
+				nums = append(nums, n)
+				rats = append(rats, r)
+			}
+		}
+	}
+	return nums, rats
+}
+
+// ratNumber formats r exactly if it has a finite decimal expansion, and to
+// avgDigits places otherwise.
+func ratNumber(r *big.Rat) Number {
+	if r.IsInt() {
+		return Number(r.Num().String())
+	}
+	var digits int
+	var d = new(big.Int).Set(r.Denom())
+	var rem = new(big.Int)
+	for _, p := range []int64{2, 5} {
+		var n int
+		var bp = big.NewInt(p)
+		for {
+			var q, m = new(big.Int).QuoRem(d, bp, rem)
+			if m.Sign() != 0 {
+				break
+			}
+			d = q
+			n++
+		}
+		if n > digits {
+			digits = n
+		}
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		digits = avgDigits
+	}
+	var s = r.FloatString(digits)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return Number(s)
+}
+
+// Sum returns the sum of the Numbers at keys, 0 if there are none.
+func (a Array) Sum(keys ...interface{}) Value {
+	_, rats := a.rats(keys...)
+	var sum = new(big.Rat)
+	for _, r := range rats {
+		sum.Add(sum, r)
+	}
+	return value(ratNumber(sum))
+}
+
+// Avg returns the mean of the Numbers at keys.
+func (a Array) Avg(keys ...interface{}) Value {
+	_, rats := a.rats(keys...)
+	if len(rats) == 0 {
+		return Value{}
+	}
+	var sum = new(big.Rat)
+	for _, r := range rats {
+		sum.Add(sum, r)
+	}
+	return value(ratNumber(sum.Quo(sum, new(big.Rat).SetInt64(int64(len(rats))))))
+}
+
+// Min returns the least Number at keys, as spelled in the Array.
+func (a Array) Min(keys ...interface{}) Value {
+	return a.extreme(-1, keys...)
+}
+
+// Max returns the greatest Number at keys, as spelled in the Array.
+func (a Array) Max(keys ...interface{}) Value {
+	return a.extreme(1, keys...)
+}
+
+func (a Array) extreme(sign int, keys ...interface{}) Value {
+	nums, rats := a.rats(keys...)
+	if len(nums) == 0 {
+		return Value{}
+	}
+	var best int
+	for i := range rats {
+		if rats[i].Cmp(rats[best]) == sign {
+			best = i
+		}
+	}
+	return value(nums[best])
+}
+
+// Count returns the number of elements with a value other than null at keys,
+// of any type.
+func (a Array) Count(keys ...interface{}) int {
+	var n int
+	for _, elem := range a {
+		if !value(elem).Get(keys...).IsNull() {
+			n++
+		}
+	}
+	return n
+}
+
+// Distinct returns the values other than null at keys, of any type, without
+// repeats and in the order first seen. Numbers are equal if their values
+// are.
+func (a Array) Distinct(keys ...interface{}) Array {
+	var seen = make(map[string]bool)
+	var dst = make(Array, 0)
+	for _, elem := range a {
+		var val = value(elem).Get(keys...)
+		if val.IsNull() {
+			continue
+		}
+		var key = string(canonicalKey(val))
+		if !seen[key] {
+			seen[key] = true
+			dst = append(dst, val.value)
+		}
+	}
+	return dst
+}
+
+// Percentile returns the p-th percentile, 0 to 100, of the Numbers at keys,
+// interpolating linearly between the nearest two as NumPy does by default.
+func (a Array) Percentile(p float64, keys ...interface{}) Value {
+	_, rats := a.rats(keys...)
+	if len(rats) == 0 || !(p >= 0 && p <= 100) {
+		return Value{}
+	}
+	sort.Slice(rats, func(i, j int) bool {
+		return rats[i].Cmp(rats[j]) < 0
+	})
+	pr, _ := new(big.Rat).SetString(strconv.FormatFloat(p, 'g', -1, 64))
+	var rank = pr.Mul(pr, big.NewRat(int64(len(rats)-1), 100))
+	var lower = new(big.Int).Quo(rank.Num(), rank.Denom())
+	var i = int(lower.Int64())
+	if i == len(rats)-1 {
+		return value(ratNumber(rats[i]))
+	}
+	var frac = rank.Sub(rank, new(big.Rat).SetInt(lower))
+	var diff = new(big.Rat).Sub(rats[i+1], rats[i])
+	return value(ratNumber(diff.Add(rats[i], diff.Mul(diff, frac))))
+}
+
+// Histogram counts the Numbers at keys into the buckets that edges, sorted
+// ascending, divide the line into: below the first edge, from each edge up
+// to the next, and from the last edge on. Every bucket is an Object with
+// "min" (inclusive), "max" (exclusive) and "count", where the outer buckets
+// have a null "min" and "max". It returns nil if an edge is not finite.
+func (a Array) Histogram(edges []float64, keys ...interface{}) Array {
+	var bounds = make([]*big.Rat, len(edges))
+	for i, e := range edges {
+		bounds[i], _ = new(big.Rat).SetString(strconv.FormatFloat(e, 'g', -1, 64))
+		if bounds[i] == nil {
+			return nil
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i].Cmp(bounds[j]) < 0
+	})
+	var counts = make([]int, len(bounds)+1)
+	_, rats := a.rats(keys...)
+	for _, r := range rats {
+		counts[sort.Search(len(bounds), func(i int) bool {
+			return bounds[i].Cmp(r) > 0
+		})]++
+	}
+
+	var buckets = make(Array, len(counts))
+	for i, n := range counts {
+		var bucket = Object{"min": nil, "max": nil, "count": value(n)}
+		if i > 0 {
+			bucket["min"] = value(ratNumber(bounds[i-1]))
+		}
+		if i < len(bounds) {
+			bucket["max"] = value(ratNumber(bounds[i]))
+		}
+		buckets[i] = bucket
+	}
+	return buckets
+}
+
+func (v Value) Sum(keys ...interface{}) Value {
+	return v.Array().Sum(keys...)
+}
+
+func (v Value) Avg(keys ...interface{}) Value {
+	return v.Array().Avg(keys...)
+}
+
+func (v Value) Min(keys ...interface{}) Value {
+	return v.Array().Min(keys...)
+}
+
+func (v Value) Max(keys ...interface{}) Value {
+	return v.Array().Max(keys...)
+}
+
+func (v Value) Count(keys ...interface{}) int {
+	return v.Array().Count(keys...)
+}
+
+func (v Value) Distinct(keys ...interface{}) Array {
+	return v.Array().Distinct(keys...)
+}
+
+func (v Value) Percentile(p float64, keys ...interface{}) Value {
+	return v.Array().Percentile(p, keys...)
+}
+
+func (v Value) Histogram(edges []float64, keys ...interface{}) Array {
+	return v.Array().Histogram(edges, keys...)
+}
@@ -0,0 +1,47 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestArray_Aggregate(t *testing.T) {
+	val, _ := Unmarshal([]byte(`[
+		{"price": 0.1, "tag": "a"},
+		{"price": 0.2, "tag": "b"},
+		{"price": "7", "tag": "a"},
+		{"price": 12345678901234567890.05, "tag": null},
+		{"tag": 1},
+		{"price": 1.0, "tag": 1.0}
+	]`))
+
+	assert.Equal(t, val.Sum("price").Number(), Number("12345678901234567891.35"))
+	assert.Equal(t, Array{0.1, 0.2}.Sum().Number(), Number("0.3"))
+	assert.Equal(t, Array{}.Sum().Number(), Number("0"))
+	assert.Equal(t, Array{1, 2, 2}.Avg().Number(), Number("1.6666666666666666666666666666666667"))
+	assert.Equal(t, Array{1, 2}.Avg().Number(), Number("1.5"))
+	assert.True(t, Array{"x"}.Avg().IsNull())
+	assert.Equal(t, val.Min("price").Number(), Number("0.1"))
+	assert.Equal(t, val.Max("price").Number(), Number("12345678901234567890.05"))
+	assert.Equal(t, Array{2, 2.0, -1e3}.Max().JSONString(), `2`)
+	assert.True(t, val.Min("missing").IsNull())
+
+	assert.Equal(t, val.Count("price"), 5)
+	assert.Equal(t, val.Count("tag"), 5)
+	assert.Equal(t, Value{value: val.Distinct("tag")}.JSONString(), `["a","b",1]`)
+
+	nums := Array{15, 20, 35, 40, 50}
+	assert.Equal(t, nums.Percentile(0).Number(), Number("15"))
+	assert.Equal(t, nums.Percentile(40).Number(), Number("29"))
+	assert.Equal(t, nums.Percentile(50).Number(), Number("35"))
+	assert.Equal(t, nums.Percentile(90).Number(), Number("46"))
+	assert.Equal(t, nums.Percentile(100).Number(), Number("50"))
+	assert.True(t, nums.Percentile(101).IsNull())
+	assert.True(t, Array{}.Percentile(50).IsNull())
+
+	assert.Equal(t, Value{value: nums.Histogram([]float64{40, 20})}.JSONString(),
+		`[{"count":1,"max":20,"min":null},{"count":2,"max":40,"min":20},{"count":2,"max":null,"min":40}]`)
+	assert.Equal(t, Value{value: val.Histogram([]float64{0.15}, "price")}.JSONString(),
+		`[{"count":1,"max":0.15,"min":null},{"count":3,"max":null,"min":0.15}]`)
+}
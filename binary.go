@@ -0,0 +1,125 @@
+package jsons
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// maxBinaryDepth bounds nesting when decoding MessagePack and CBOR.
+const maxBinaryDepth = 10000
+
+// binaryValue normalizes src for the binary encoders, which unlike JSON keep
+// []byte as binary data.
+func binaryValue(src interface{}) (interface{}, error) {
+	if b, ok := src.([]byte); ok {
+		return b, nil
+	}
+	return normalize(src)
+}
+
+// binaryNumber splits n into an integer, for integer spellings, or a float.
+func binaryNumber(n Number) (*big.Int, float64, error) {
+	if n == "" {
+		return new(big.Int), 0, nil
+	}
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, ok := new(big.Int).SetString(string(n), 10); ok {
+			return i, 0, nil
+		}
+	}
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, 0, fmt.Errorf("jsons: invalid number %q", string(n))
+	}
+	return nil, f, nil
+}
+
+// isFloat32 reports whether f survives a round trip through float32.
+func isFloat32(f float64) bool {
+	return float64(float32(f)) == f
+}
+
+type binaryReader struct {
+	format string
+	data   []byte
+	pos    int
+	depth  int
+}
+
+func (r *binaryReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("jsons: %s: offset %d: %s", r.format, r.pos, fmt.Sprintf(format, args...))
+}
+
+// float converts a decoded float to a Number; infinities and NaN have no
+// JSON form and are an error.
+func (r *binaryReader) float(f float64) (interface{}, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, r.errorf("%v is not a valid JSON number", f)
+	}
+	return json.Number(formatFloat(f)), nil
+}
+
+func (r *binaryReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, r.errorf("unexpected end of data")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *binaryReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// uint reads an n byte big-endian unsigned integer.
+func (r *binaryReader) uint(n int) (uint64, error) {
+	b, err := r.next(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// items checks that n items of at least size bytes each can still follow,
+// so a forged length cannot trigger a huge allocation.
+func (r *binaryReader) items(n uint64, size uint64) (int, error) {
+	if n > uint64(len(r.data)-r.pos)/size {
+		return 0, r.errorf("length %d exceeds remaining data", n)
+	}
+	return int(n), nil
+}
+
+func (r *binaryReader) enter() error {
+	if r.depth++; r.depth > maxBinaryDepth {
+		return r.errorf("%v", ErrMaxDepth)
+	}
+	return nil
+}
+
+func (r *binaryReader) leave() {
+	r.depth--
+}
+
+func (r *binaryReader) end() error {
+	if r.pos != len(r.data) {
+		return r.errorf("%v", ErrTrailingData)
+	}
+	return nil
+}
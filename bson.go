@@ -0,0 +1,735 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExtJSONMode selects the MongoDB Extended JSON v2 form that BSON types are
+// decoded to.
+type ExtJSONMode int
+
+const (
+	// ExtJSONRelaxed decodes int32, int64 and finite doubles to plain
+	// Numbers and dates between 1970 and 9999 to ISO-8601 Strings.
+	ExtJSONRelaxed ExtJSONMode = iota
+	// ExtJSONCanonical wraps every number and date, as in
+	// {"$numberLong": "1"}, so the exact BSON type survives a round trip.
+	ExtJSONCanonical
+)
+
+// UnmarshalBSON decodes a BSON document. Types JSON lacks become Extended JSON
+// objects such as {"$oid": "..."} or {"$numberDecimal": "..."}, which
+// MarshalBSON turns back into the same BSON types.
+func UnmarshalBSON(data []byte, mode ExtJSONMode) (Value, error) {
+	var d = bsonDecoder{binaryReader: binaryReader{format: "bson", data: data}, mode: mode}
+	doc, err := d.document(false)
+	if err != nil {
+		return Value{}, err
+	}
+	if err = d.end(); err != nil {
+		return Value{}, err
+	}
+	return Value{value: doc}, nil
+}
+
+// MarshalBSON encodes the value, which must be an object, as a BSON document.
+// Integer Numbers become int32 or int64, other Numbers doubles, and Numbers a
+// double cannot hold exactly decimal128. Extended JSON objects in canonical
+// or relaxed form become the BSON types they describe.
+func (v Value) MarshalBSON() ([]byte, error) {
+	src, err := normalize(v.value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := src.(Object); !ok {
+		return nil, fmt.Errorf("jsons: bson: document root must be an object, not %s", typeName(src))
+	}
+	var buf bytes.Buffer
+	if err = bsonDocument(&buf, src, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (v *Value) UnmarshalBSON(data []byte) error {
+	val, err := UnmarshalBSON(data, ExtJSONRelaxed)
+	if err != nil {
+		return err
+	}
+	*v = val
+	return nil
+}
+
+func (o Object) MarshalBSON() ([]byte, error) {
+	return Value{value: o}.MarshalBSON()
+}
+
+func (o *Object) UnmarshalBSON(data []byte) error {
+	val, err := UnmarshalBSON(data, ExtJSONRelaxed)
+	if err != nil {
+		return err
+	}
+	*o = val.Object()
+	return nil
+}
+
+// MarshalBSON encodes the array as a BSON array document keyed "0", "1", ...
+func (a Array) MarshalBSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := bsonDocument(&buf, a, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBSON decodes the elements of a BSON document in order, ignoring
+// their keys.
+func (a *Array) UnmarshalBSON(data []byte) error {
+	var d = bsonDecoder{binaryReader: binaryReader{format: "bson", data: data}}
+	arr, err := d.document(true)
+	if err != nil {
+		return err
+	}
+	if err = d.end(); err != nil {
+		return err
+	}
+	*a = arr.([]interface{})
+	return nil
+}
+
+// ExtendedJSON encodes the value at keys as MongoDB Extended JSON v2.
+func (v Value) ExtendedJSON(mode ExtJSONMode, keys ...interface{}) ([]byte, error) {
+	// A round trip through BSON picks the BSON type of every Number and
+	// rewrites Extended JSON objects in the requested form.
+	data, err := value(Object{"v": v.Get(keys...).value}).MarshalBSON()
+	if err != nil {
+		return nil, err
+	}
+	val, err := UnmarshalBSON(data, mode)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(val.Object()["v"])
+}
+
+func bsonDocument(buf *bytes.Buffer, src interface{}, path []interface{}) error {
+	var start = buf.Len()
+	buf.Write([]byte{0, 0, 0, 0})
+	switch v := src.(type) {
+	case Object:
+		for _, key := range sortedKeys(v) {
+			if err := bsonElement(buf, key, v[key], append(path, key)); err != nil {
+				return err
+			}
+		}
+	case Array:
+		for i, elem := range v {
+			if err := bsonElement(buf, strconv.Itoa(i), elem, append(path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	buf.WriteByte(0)
+	binary.LittleEndian.PutUint32(buf.Bytes()[start:], uint32(buf.Len()-start))
+	return nil
+}
+
+func bsonElement(buf *bytes.Buffer, key string, src interface{}, path []interface{}) error {
+	if strings.IndexByte(key, 0) >= 0 {
+		return bsonError(path, "key contains a NUL byte")
+	}
+	var pos = buf.Len()
+	buf.WriteByte(0)
+	buf.WriteString(key)
+	buf.WriteByte(0)
+	typ, err := bsonValue(buf, src, path)
+	if err != nil {
+		return err
+	}
+	buf.Bytes()[pos] = typ
+	return nil
+}
+
+func bsonError(path []interface{}, format string, args ...interface{}) error {
+	return fmt.Errorf("jsons: bson: %s: %s", JSONPath(path...), fmt.Sprintf(format, args...))
+}
+
+func bsonValue(buf *bytes.Buffer, src interface{}, path []interface{}) (byte, error) {
+	src, err := binaryValue(src)
+	if err != nil {
+		return 0, err
+	}
+	switch v := src.(type) {
+	case nil:
+		return 0x0a, nil
+	case Bool:
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		return 0x08, nil
+	case Number:
+		return bsonNumber(buf, v, path)
+	case String:
+		bsonString(buf, string(v))
+		return 0x02, nil
+	case []byte:
+		bsonBinary(buf, v, 0)
+		return 0x05, nil
+	case Array:
+		return 0x04, bsonDocument(buf, v, path)
+	case Object:
+		if typ, ok, err := bsonExtended(buf, v, path); ok || err != nil {
+			return typ, err
+		}
+		return 0x03, bsonDocument(buf, v, path)
+	}
+	return 0, bsonError(path, "cannot encode %T", src)
+}
+
+func bsonString(buf *bytes.Buffer, s string) {
+	bsonInt32(buf, int32(len(s)+1))
+	buf.WriteString(s)
+	buf.WriteByte(0)
+}
+
+func bsonInt32(buf *bytes.Buffer, i int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(i))
+	buf.Write(b[:])
+}
+
+func bsonInt64(buf *bytes.Buffer, i int64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(i))
+	buf.Write(b[:])
+}
+
+func bsonBinary(buf *bytes.Buffer, data []byte, subtype byte) {
+	if subtype == 0x02 {
+		// The old binary subtype repeats the length inside the payload.
+		bsonInt32(buf, int32(len(data)+4))
+		buf.WriteByte(subtype)
+		bsonInt32(buf, int32(len(data)))
+	} else {
+		bsonInt32(buf, int32(len(data)))
+		buf.WriteByte(subtype)
+	}
+	buf.Write(data)
+}
+
+func bsonNumber(buf *bytes.Buffer, n Number, path []interface{}) (byte, error) {
+	var s = string(n)
+	switch s {
+	case "":
+		s = "0"
+	case "Infinity", "+Infinity", "-Infinity", "NaN":
+		f, _ := strconv.ParseFloat(s, 64)
+		bsonInt64(buf, int64(math.Float64bits(f)))
+		return 0x01, nil
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			if i >= math.MinInt32 && i <= math.MaxInt32 {
+				bsonInt32(buf, int32(i))
+				return 0x10, nil
+			}
+			bsonInt64(buf, i)
+			return 0x12, nil
+		}
+	} else if f, err := strconv.ParseFloat(s, 64); err == nil && sameDecimal(s, formatFloat(f)) {
+		bsonInt64(buf, int64(math.Float64bits(f)))
+		return 0x01, nil
+	}
+	return bsonDecimal(buf, s, path)
+}
+
+func bsonDecimal(buf *bytes.Buffer, s string, path []interface{}) (byte, error) {
+	high, low, err := parseDecimal128(s)
+	if err != nil {
+		return 0, bsonError(path, "%v", strings.TrimPrefix(err.Error(), "jsons: "))
+	}
+	bsonInt64(buf, int64(low))
+	bsonInt64(buf, int64(high))
+	return 0x13, nil
+}
+
+// sameDecimal reports whether two decimal spellings have the same value.
+func sameDecimal(a, b string) bool {
+	var key = func(s string) (string, int) {
+		var sign string
+		if strings.HasPrefix(s, "-") {
+			sign, s = "-", s[1:]
+		}
+		var exp int
+		if i := strings.IndexAny(s, "eE"); i >= 0 {
+			exp, _ = strconv.Atoi(s[i+1:])
+			s = s[:i]
+		}
+		if i := strings.IndexByte(s, '.'); i >= 0 {
+			exp -= len(s) - i - 1
+			s = s[:i] + s[i+1:]
+		}
+		s = strings.TrimLeft(s, "0")
+		var digits = strings.TrimRight(s, "0")
+		if digits == "" {
+			return "0", 0
+		}
+		return sign + digits, exp + len(s) - len(digits)
+	}
+	da, ea := key(strings.TrimPrefix(a, "+"))
+	db, eb := key(strings.TrimPrefix(b, "+"))
+	return da == db && ea == eb
+}
+
+// bsonExtended encodes obj as the BSON type it describes in Extended JSON.
+// It reports false, writing nothing, if obj is an ordinary object.
+func bsonExtended(buf *bytes.Buffer, obj Object, path []interface{}) (typ byte, ok bool, err error) {
+	var str = func(v interface{}) (string, bool) {
+		s, ok := original(value(v).value).(string)
+		return s, ok
+	}
+	var invalid = func(key string) (byte, bool, error) {
+		return 0, true, bsonError(path, "invalid %s", key)
+	}
+
+	var key string
+	switch len(obj) {
+	case 1:
+		for key = range obj {
+		}
+	case 2:
+		if _, ok := obj["$scope"]; ok {
+			key = "$code"
+		} else if _, ok := obj["$type"]; ok {
+			key = "$binary"
+		}
+	}
+	val, exists := obj[key]
+	if !exists {
+		return 0, false, nil
+	}
+
+	switch key {
+	case "$oid":
+		s, _ := str(val)
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != 12 {
+			return invalid(key)
+		}
+		buf.Write(b)
+		return 0x07, true, nil
+	case "$date":
+		var ms int64
+		switch v := value(val); {
+		case v.IsString():
+			t, err := time.Parse(time.RFC3339Nano, v.String())
+			if err != nil {
+				return invalid(key)
+			}
+			ms = t.Unix()*1e3 + int64(t.Nanosecond())/1e6
+		case v.IsObject() && v.Len() == 1 && v.IsString("$numberLong"):
+			if ms, err = strconv.ParseInt(v.String("$numberLong"), 10, 64); err != nil {
+				return invalid(key)
+			}
+		case v.IsNumber():
+			if ms, err = strconv.ParseInt(string(v.Number()), 10, 64); err != nil {
+				return invalid(key)
+			}
+		default:
+			return invalid(key)
+		}
+		bsonInt64(buf, ms)
+		return 0x09, true, nil
+	case "$numberInt":
+		s, _ := str(val)
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return invalid(key)
+		}
+		bsonInt32(buf, int32(i))
+		return 0x10, true, nil
+	case "$numberLong":
+		s, _ := str(val)
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return invalid(key)
+		}
+		bsonInt64(buf, i)
+		return 0x12, true, nil
+	case "$numberDouble":
+		s, _ := str(val)
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return invalid(key)
+		}
+		bsonInt64(buf, int64(math.Float64bits(f)))
+		return 0x01, true, nil
+	case "$numberDecimal":
+		s, _ := str(val)
+		typ, err := bsonDecimal(buf, s, path)
+		return typ, true, err
+	case "$binary":
+		var data, subtype string
+		if len(obj) == 2 {
+			data, _ = str(val)
+			subtype, _ = str(obj["$type"])
+		} else {
+			v := value(val)
+			if v.Len() != 2 || !v.IsString("base64") || !v.IsString("subType") {
+				return invalid(key)
+			}
+			data, subtype = v.String("base64"), v.String("subType")
+		}
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return invalid(key)
+		}
+		st, err := strconv.ParseUint(subtype, 16, 8)
+		if err != nil || len(subtype) > 2 {
+			return invalid(key)
+		}
+		bsonBinary(buf, b, byte(st))
+		return 0x05, true, nil
+	case "$regularExpression":
+		v := value(val)
+		if v.Len() != 2 || !v.IsString("pattern") || !v.IsString("options") {
+			return invalid(key)
+		}
+		var pattern, options = v.String("pattern"), []byte(v.String("options"))
+		if strings.IndexByte(pattern, 0) >= 0 || bytes.IndexByte(options, 0) >= 0 {
+			return invalid(key)
+		}
+		sort.Slice(options, func(i, j int) bool { return options[i] < options[j] })
+		buf.WriteString(pattern)
+		buf.WriteByte(0)
+		buf.Write(options)
+		buf.WriteByte(0)
+		return 0x0b, true, nil
+	case "$timestamp":
+		v := value(val)
+		t, err1 := strconv.ParseUint(string(v.Number("t")), 10, 32)
+		i, err2 := strconv.ParseUint(string(v.Number("i")), 10, 32)
+		if v.Len() != 2 || err1 != nil || err2 != nil {
+			return invalid(key)
+		}
+		bsonInt64(buf, int64(t<<32|i))
+		return 0x11, true, nil
+	case "$minKey", "$maxKey":
+		if value(val).Int() != 1 {
+			return invalid(key)
+		}
+		if key == "$minKey" {
+			return 0xff, true, nil
+		}
+		return 0x7f, true, nil
+	case "$undefined":
+		if !value(val).Bool() {
+			return invalid(key)
+		}
+		return 0x06, true, nil
+	case "$symbol":
+		s, ok := str(val)
+		if !ok {
+			return invalid(key)
+		}
+		bsonString(buf, s)
+		return 0x0e, true, nil
+	case "$code":
+		s, ok := str(val)
+		if !ok {
+			return invalid(key)
+		}
+		if len(obj) == 1 {
+			bsonString(buf, s)
+			return 0x0d, true, nil
+		}
+		scope, ok := value(obj["$scope"]).value.(Object)
+		if !ok {
+			return invalid("$scope")
+		}
+		var start = buf.Len()
+		bsonInt32(buf, 0)
+		bsonString(buf, s)
+		if err = bsonDocument(buf, scope, append(path, "$scope")); err != nil {
+			return 0, true, err
+		}
+		binary.LittleEndian.PutUint32(buf.Bytes()[start:], uint32(buf.Len()-start))
+		return 0x0f, true, nil
+	case "$dbPointer":
+		v := value(val)
+		b, err := hex.DecodeString(v.String("$id", "$oid"))
+		if v.Len() != 2 || !v.IsString("$ref") || v.Len("$id") != 1 || err != nil || len(b) != 12 {
+			return invalid(key)
+		}
+		bsonString(buf, v.String("$ref"))
+		buf.Write(b)
+		return 0x0c, true, nil
+	}
+	return 0, false, nil
+}
+
+type bsonDecoder struct {
+	binaryReader
+	mode ExtJSONMode
+}
+
+func (d *bsonDecoder) int32() (int32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (d *bsonDecoder) int64() (int64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (d *bsonDecoder) cstring() (string, error) {
+	var i = bytes.IndexByte(d.data[d.pos:], 0)
+	if i < 0 {
+		return "", d.errorf("unterminated cstring")
+	}
+	var s = string(d.data[d.pos : d.pos+i])
+	d.pos += i + 1
+	return s, nil
+}
+
+func (d *bsonDecoder) string() (string, error) {
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+	if n < 1 {
+		return "", d.errorf("invalid string length %d", n)
+	}
+	b, err := d.next(uint64(n))
+	if err != nil {
+		return "", err
+	}
+	if b[n-1] != 0 {
+		return "", d.errorf("string is not NUL terminated")
+	}
+	return string(b[:n-1]), nil
+}
+
+func (d *bsonDecoder) document(array bool) (interface{}, error) {
+	var start = d.pos
+	n, err := d.int32()
+	if err != nil {
+		return nil, err
+	}
+	if n < 5 || int(n) > len(d.data)-start {
+		d.pos = start
+		return nil, d.errorf("invalid document length %d", n)
+	}
+	var end = start + int(n)
+	if err = d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	var obj = make(map[string]interface{})
+	var arr = make([]interface{}, 0)
+	for {
+		typ, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if typ == 0 {
+			break
+		}
+		key, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		val, err := d.element(typ)
+		if err != nil {
+			return nil, err
+		}
+		if d.pos > end {
+			break
+		}
+		if array {
+			arr = append(arr, val)
+		} else {
+			obj[key] = val
+		}
+	}
+	if d.pos != end {
+		d.pos = start
+		return nil, d.errorf("document length %d does not match its content", n)
+	}
+	if array {
+		return arr, nil
+	}
+	return obj, nil
+}
+
+func (d *bsonDecoder) element(typ byte) (interface{}, error) {
+	switch typ {
+	case 0x01:
+		i, err := d.int64()
+		return d.double(math.Float64frombits(uint64(i))), err
+	case 0x02:
+		return d.string()
+	case 0x03:
+		return d.document(false)
+	case 0x04:
+		return d.document(true)
+	case 0x05:
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+		subtype, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, d.errorf("invalid binary length %d", n)
+		}
+		if subtype == 0x02 {
+			if m, err := d.int32(); err != nil || m != n-4 {
+				return nil, d.errorf("invalid old binary length")
+			}
+			n -= 4
+		}
+		b, err := d.next(uint64(n))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$binary": map[string]interface{}{
+			"base64":  base64.StdEncoding.EncodeToString(b),
+			"subType": fmt.Sprintf("%02x", subtype),
+		}}, nil
+	case 0x06:
+		return map[string]interface{}{"$undefined": true}, nil
+	case 0x07:
+		b, err := d.next(12)
+		return map[string]interface{}{"$oid": hex.EncodeToString(b)}, err
+	case 0x08:
+		b, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b > 1 {
+			return nil, d.errorf("invalid boolean %d", b)
+		}
+		return b == 1, nil
+	case 0x09:
+		ms, err := d.int64()
+		return d.date(ms), err
+	case 0x0a:
+		return nil, nil
+	case 0x0b:
+		pattern, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		options, err := d.cstring()
+		return map[string]interface{}{"$regularExpression": map[string]interface{}{
+			"pattern": pattern,
+			"options": options,
+		}}, err
+	case 0x0c:
+		ref, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(12)
+		return map[string]interface{}{"$dbPointer": map[string]interface{}{
+			"$ref": ref,
+			"$id":  map[string]interface{}{"$oid": hex.EncodeToString(b)},
+		}}, err
+	case 0x0d, 0x0e:
+		s, err := d.string()
+		if typ == 0x0e {
+			return map[string]interface{}{"$symbol": s}, err
+		}
+		return map[string]interface{}{"$code": s}, err
+	case 0x0f:
+		var start = d.pos
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+		code, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		scope, err := d.document(false)
+		if err != nil {
+			return nil, err
+		}
+		if d.pos-start != int(n) {
+			d.pos = start
+			return nil, d.errorf("code with scope length %d does not match its content", n)
+		}
+		return map[string]interface{}{"$code": code, "$scope": scope}, nil
+	case 0x10:
+		i, err := d.int32()
+		return d.integer("$numberInt", int64(i)), err
+	case 0x11:
+		i, err := d.int64()
+		return map[string]interface{}{"$timestamp": map[string]interface{}{
+			"t": json.Number(strconv.FormatUint(uint64(i)>>32, 10)),
+			"i": json.Number(strconv.FormatUint(uint64(i)&math.MaxUint32, 10)),
+		}}, err
+	case 0x12:
+		i, err := d.int64()
+		return d.integer("$numberLong", i), err
+	case 0x13:
+		low, err := d.int64()
+		if err != nil {
+			return nil, err
+		}
+		high, err := d.int64()
+		return map[string]interface{}{"$numberDecimal": formatDecimal128(uint64(high), uint64(low))}, err
+	case 0x7f:
+		return map[string]interface{}{"$maxKey": json.Number("1")}, nil
+	case 0xff:
+		return map[string]interface{}{"$minKey": json.Number("1")}, nil
+	}
+	d.pos--
+	return nil, d.errorf("unsupported element type 0x%02x", typ)
+}
+
+func (d *bsonDecoder) integer(key string, i int64) interface{} {
+	var n = json.Number(strconv.FormatInt(i, 10))
+	if d.mode == ExtJSONCanonical {
+		return map[string]interface{}{key: string(n)}
+	}
+	return n
+}
+
+func (d *bsonDecoder) double(f float64) interface{} {
+	if d.mode == ExtJSONCanonical || math.IsInf(f, 0) || math.IsNaN(f) {
+		return map[string]interface{}{"$numberDouble": formatFloat(f)}
+	}
+	return json.Number(formatFloat(f))
+}
+
+func (d *bsonDecoder) date(ms int64) interface{} {
+	var t = time.Unix(ms/1e3, ms%1e3*1e6).UTC()
+	if d.mode == ExtJSONCanonical || t.Year() < 1970 || t.Year() > 9999 {
+		return map[string]interface{}{"$date": map[string]interface{}{"$numberLong": strconv.FormatInt(ms, 10)}}
+	}
+	return map[string]interface{}{"$date": t.Format("2006-01-02T15:04:05.999Z07:00")}
+}
@@ -0,0 +1,138 @@
+package jsons
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_MarshalBSON(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"hello": "world"}`))
+	data, err := val.MarshalBSON()
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "\x16\x00\x00\x00\x02hello\x00\x06\x00\x00\x00world\x00\x00")
+
+	val, _ = Unmarshal([]byte(`{
+		"int32": 1, "int64": 4294967296, "double": 1.5, "whole": 2.0,
+		"decimal": 0.10000000000000000001, "huge": 123456789012345678901234567890,
+		"list": [true, null, "x"],
+		"id": {"$oid": "5f1e7b2c9d3b2a0001a1b2c3"},
+		"at": {"$date": "2022-05-01T10:20:30.5Z"},
+		"old": {"$date": {"$numberLong": "-86400000"}},
+		"long": {"$numberLong": "7"},
+		"dec": {"$numberDecimal": "1.50"},
+		"inf": {"$numberDouble": "-Infinity"},
+		"bin": {"$binary": {"base64": "AQID", "subType": "04"}},
+		"re": {"$regularExpression": {"pattern": "^a", "options": "xi"}},
+		"ts": {"$timestamp": {"t": 1651400430, "i": 2}},
+		"min": {"$minKey": 1},
+		"code": {"$code": "f()", "$scope": {"a": 1}},
+		"ptr": {"$dbPointer": {"$ref": "c", "$id": {"$oid": "5f1e7b2c9d3b2a0001a1b2c3"}}},
+		"plain": {"$ref": "x"}
+	}`))
+	data, err = val.MarshalBSON()
+	assert.NoError(t, err)
+
+	back, err := UnmarshalBSON(data, ExtJSONRelaxed)
+	assert.NoError(t, err)
+	assert.Equal(t, back.Number("int64"), Number("4294967296"))
+	assert.Equal(t, back.Number("double"), Number("1.5"))
+	assert.Equal(t, back.Number("whole"), Number("2.0"))
+	assert.Equal(t, back.String("decimal", "$numberDecimal"), "0.10000000000000000001")
+	assert.Equal(t, back.String("huge", "$numberDecimal"), "123456789012345678901234567890")
+	assert.Equal(t, back.String("id", "$oid"), "5f1e7b2c9d3b2a0001a1b2c3")
+	assert.Equal(t, back.String("at", "$date"), "2022-05-01T10:20:30.5Z")
+	assert.Equal(t, back.String("old", "$date", "$numberLong"), "-86400000")
+	assert.Equal(t, back.Number("long"), Number("7"))
+	assert.Equal(t, back.String("dec", "$numberDecimal"), "1.50")
+	assert.Equal(t, back.String("inf", "$numberDouble"), "-Infinity")
+	assert.Equal(t, back.String("re", "$regularExpression", "options"), "ix")
+	assert.Equal(t, back.Int("ts", "$timestamp", "t"), int64(1651400430))
+	assert.Equal(t, back.Int("code", "$scope", "a"), int64(1))
+	assert.Equal(t, back.String("plain", "$ref"), "x")
+
+	// Only the canonical form keeps int64s that fit in an int32.
+	canonical, err := UnmarshalBSON(data, ExtJSONCanonical)
+	assert.NoError(t, err)
+	assert.Equal(t, canonical.String("int32", "$numberInt"), "1")
+	assert.Equal(t, canonical.String("long", "$numberLong"), "7")
+	assert.Equal(t, canonical.String("whole", "$numberDouble"), "2.0")
+	assert.Equal(t, canonical.String("at", "$date", "$numberLong"), "1651400430500")
+	again, err := canonical.MarshalBSON()
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(again), hex.EncodeToString(data))
+
+	for _, doc := range []string{
+		`[1]`, `{"a": {"$oid": "zz"}}`, `{"a": {"$numberInt": "4294967296"}}`,
+		`{"a": 1e99999}`, `{"a": {"$date": true}}`, "{\"a\\u0000\": 1}",
+	} {
+		val, _ := Unmarshal([]byte(doc))
+		_, err := val.MarshalBSON()
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestValue_ExtendedJSON(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"n": 1, "f": 1.5, "d": {"$date": "2022-05-01T10:20:30Z"}, "l": {"$numberLong": "2"}}`))
+	data, err := val.ExtendedJSON(ExtJSONCanonical)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"d":{"$date":{"$numberLong":"1651400430000"}},"f":{"$numberDouble":"1.5"},"l":{"$numberLong":"2"},"n":{"$numberInt":"1"}}`)
+
+	data, err = val.ExtendedJSON(ExtJSONRelaxed)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"d":{"$date":"2022-05-01T10:20:30Z"},"f":1.5,"l":2,"n":1}`)
+
+	data, err = val.ExtendedJSON(ExtJSONCanonical, "n")
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"$numberInt":"1"}`)
+}
+
+func TestBSON_Array(t *testing.T) {
+	var arr = Array{"a", 1}
+	data, err := arr.MarshalBSON()
+	assert.NoError(t, err)
+	var back Array
+	assert.NoError(t, back.UnmarshalBSON(data))
+	assert.Equal(t, back.JSONString(), `["a",1]`)
+
+	var obj Object
+	assert.NoError(t, obj.UnmarshalBSON(data))
+	assert.Equal(t, obj.String("0"), "a")
+
+	for _, s := range []string{"", "05000000", "0600000000", "0c0000000861000200000000", "0500000000ff"} {
+		data, _ := hex.DecodeString(s)
+		assert.Error(t, back.UnmarshalBSON(data), s)
+	}
+}
+
+func TestDecimal128(t *testing.T) {
+	for _, c := range []struct {
+		in, hex, out string
+	}{
+		{"1", "30400000000000000000000000000001", "1"},
+		{"-0", "b0400000000000000000000000000000", "-0"},
+		{"0.1", "303e0000000000000000000000000001", "0.1"},
+		{"1.50", "303c0000000000000000000000000096", "1.50"},
+		{"1E+3", "30460000000000000000000000000001", "1E+3"},
+		{"0.0000001", "30320000000000000000000000000001", "1E-7"},
+		{"Infinity", "78000000000000000000000000000000", "Infinity"},
+		{"NaN", "7c000000000000000000000000000000", "NaN"},
+		{"9999999999999999999999999999999999", "3041ed09bead87c0378d8e63ffffffff", "9999999999999999999999999999999999"},
+		{"1E+6144", "5ffe314dc6448d9338c15b0a00000000", "1.000000000000000000000000000000000E+6144"},
+		{"10E-6177", "00000000000000000000000000000001", "1E-6176"},
+	} {
+		high, low, err := parseDecimal128(c.in)
+		assert.NoError(t, err, c.in)
+		assert.Equal(t, hex.EncodeToString([]byte{
+			byte(high >> 56), byte(high >> 48), byte(high >> 40), byte(high >> 32), byte(high >> 24), byte(high >> 16), byte(high >> 8), byte(high),
+			byte(low >> 56), byte(low >> 48), byte(low >> 40), byte(low >> 32), byte(low >> 24), byte(low >> 16), byte(low >> 8), byte(low),
+		}), c.hex, c.in)
+		assert.Equal(t, formatDecimal128(high, low), c.out, c.in)
+	}
+
+	for _, s := range []string{"", "abc", "1.2.3", "1E+6200", "1E-6200", "12345678901234567890123456789012345"} {
+		_, _, err := parseDecimal128(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,156 @@
+package jsons
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// canonical writes src in the RFC 8785 JSON Canonicalization Scheme.
+func canonical(buf *bytes.Buffer, src interface{}) error {
+	src, err := normalize(src)
+	if err != nil {
+		return err
+	}
+	switch v := src.(type) {
+	case nil:
+		buf.WriteString("null")
+	case Bool:
+		buf.WriteString(strconv.FormatBool(bool(v)))
+	case Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf("jsons: invalid number %q", string(v))
+		}
+		num, err := es6Number(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(num)
+	case String:
+		return canonicalString(buf, string(v))
+	case Array:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = canonical(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case Object:
+		var keys = make([]string, 0, len(v))
+		var units = make(map[string][]uint16, len(v))
+		for key := range v {
+			keys = append(keys, key)
+			units[key] = utf16.Encode([]rune(key))
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := units[keys[i]], units[keys[j]]
+			for k := 0; k < len(a) && k < len(b); k++ {
+				if a[k] != b[k] {
+					return a[k] < b[k]
+				}
+			}
+			return len(a) < len(b)
+		})
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = canonicalString(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err = canonical(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+// es6Number formats f like ECMAScript's Number.prototype.toString.
+func es6Number(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("jsons: NaN and Infinity are not valid JSON numbers")
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	var sign string
+	if f < 0 {
+		f, sign = -f, "-"
+	}
+	var format byte = 'e'
+	if f >= 1e-6 && f < 1e21 {
+		format = 'f'
+	}
+	num := strconv.FormatFloat(f, format, -1, 64)
+	// Go writes exponents with at least two digits ("1e+09").
+	if e := strings.IndexByte(num, 'e'); e > 0 && num[e+2] == '0' {
+		num = num[:e+2] + num[e+3:]
+	}
+	return sign + num, nil
+}
+
+func canonicalString(buf *bytes.Buffer, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("jsons: invalid UTF-8 in string %q", s)
+	}
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0xf])
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return nil
+}
+
+func (v Value) Canonical(keys ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := canonical(&buf, v.Get(keys...).value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r Raw) Canonical(keys ...interface{}) ([]byte, error) {
+	val, err := Unmarshal(r.Get(keys...))
+	if err != nil {
+		return nil, err
+	}
+	return val.Canonical()
+}
@@ -0,0 +1,45 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_Canonical(t *testing.T) {
+	// Example from RFC 8785, section 3.2.2.
+	raw := Raw(`{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "€$\u000F\u000aA'B\"\\\\\"\/",
+		"literals": [null, true, false]
+	}`)
+	data, err := raw.Canonical()
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`)
+
+	// Keys sort by UTF-16 code units, not by UTF-8 bytes.
+	val, err := Unmarshal([]byte(`{"\ufb33": 2, "\ud83d\ude00": 1, "a": {"1.0": 1.0, "b": "<&>"}}`))
+	assert.NoError(t, err)
+	data, err = val.Canonical()
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "{\"a\":{\"1.0\":1,\"b\":\"<&>\"},\"\U0001F600\":1,\"\uFB33\":2}")
+
+	data, err = val.Canonical("a", "1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `1`)
+
+	for num, expected := range map[Number]string{
+		"0": "0", "-0": "0", "1e21": "1e+21", "1e20": "100000000000000000000",
+		"0.000001": "0.000001", "0.0000001": "1e-7", "-1.5e-10": "-1.5e-10",
+		"9007199254740993": "9007199254740992",
+	} {
+		data, err = value(num).Canonical()
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), expected, string(num))
+	}
+
+	_, err = value(Number("1e400")).Canonical()
+	assert.Error(t, err)
+	_, err = value("\xff").Canonical()
+	assert.Error(t, err)
+}
@@ -0,0 +1,460 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MarshalCBOR encodes the value as CBOR (RFC 8949) using the deterministic
+// encoding of section 4.2. Integers beyond 64 bits are written as bignums and
+// []byte as a byte string.
+func (v Value) MarshalCBOR() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeCBOR(&buf, v.value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR decodes a CBOR document. Floats keep a fraction so they
+// remain floats, byte strings become []byte, bignums and decimal fractions
+// Numbers and epoch times Strings in TimeLayout. Other tags are ignored.
+func (v *Value) UnmarshalCBOR(data []byte) error {
+	var r = binaryReader{format: "cbor", data: data}
+	val, err := decodeCBOR(&r)
+	if err != nil {
+		return err
+	}
+	if err = r.end(); err != nil {
+		return err
+	}
+	if val == cborBreak {
+		return r.errorf("unexpected break")
+	}
+	v.value = val
+	return nil
+}
+
+const (
+	cborUint byte = iota << 5
+	cborNegint
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// cborBreak is returned by decodeCBOR for the break stop code.
+var cborBreak = new(struct{})
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	var b [9]byte
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		b[0] = major | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		buf.Write(b[:3])
+	case n <= math.MaxUint32:
+		b[0] = major | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		buf.Write(b[:5])
+	default:
+		b[0] = major | 27
+		binary.BigEndian.PutUint64(b[1:], n)
+		buf.Write(b[:9])
+	}
+}
+
+func encodeCBOR(buf *bytes.Buffer, src interface{}) error {
+	src, err := binaryValue(src)
+	if err != nil {
+		return err
+	}
+	switch v := src.(type) {
+	case nil:
+		buf.WriteByte(cborSimple | 22)
+	case Bool:
+		if v {
+			buf.WriteByte(cborSimple | 21)
+		} else {
+			buf.WriteByte(cborSimple | 20)
+		}
+	case Number:
+		return cborNumber(buf, v)
+	case String:
+		cborHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(string(v))
+	case []byte:
+		cborHead(buf, cborBytes, uint64(len(v)))
+		buf.Write(v)
+	case Array:
+		cborHead(buf, cborArray, uint64(len(v)))
+		for _, elem := range v {
+			if err = encodeCBOR(buf, elem); err != nil {
+				return err
+			}
+		}
+	case Object:
+		// Deterministic encoding sorts keys by their encoded bytes, which
+		// for text strings means shorter keys first.
+		var keys = sortedKeys(v)
+		sort.SliceStable(keys, func(i, j int) bool {
+			return len(keys[i]) < len(keys[j])
+		})
+		cborHead(buf, cborMap, uint64(len(v)))
+		for _, key := range keys {
+			cborHead(buf, cborText, uint64(len(key)))
+			buf.WriteString(key)
+			if err = encodeCBOR(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("jsons: cbor: cannot encode %T", src)
+	}
+	return nil
+}
+
+func cborNumber(buf *bytes.Buffer, n Number) error {
+	i, f, err := binaryNumber(n)
+	if err != nil {
+		return err
+	}
+	var b [9]byte
+	switch {
+	case i == nil:
+		if h, ok := toFloat16(f); ok {
+			b[0] = cborSimple | 25
+			binary.BigEndian.PutUint16(b[1:], h)
+			buf.Write(b[:3])
+			return nil
+		}
+		if isFloat32(f) {
+			b[0] = cborSimple | 26
+			binary.BigEndian.PutUint32(b[1:], math.Float32bits(float32(f)))
+			buf.Write(b[:5])
+			return nil
+		}
+		b[0] = cborSimple | 27
+		binary.BigEndian.PutUint64(b[1:], math.Float64bits(f))
+		buf.Write(b[:9])
+	case i.Sign() >= 0 && i.IsUint64():
+		cborHead(buf, cborUint, i.Uint64())
+	case i.Sign() >= 0:
+		cborHead(buf, cborTag, 2)
+		cborHead(buf, cborBytes, uint64(len(i.Bytes())))
+		buf.Write(i.Bytes())
+	default:
+		// Negative integers are stored as -1 - n.
+		var m = new(big.Int).Sub(new(big.Int).Neg(i), big.NewInt(1))
+		if m.IsUint64() {
+			cborHead(buf, cborNegint, m.Uint64())
+			return nil
+		}
+		cborHead(buf, cborTag, 3)
+		cborHead(buf, cborBytes, uint64(len(m.Bytes())))
+		buf.Write(m.Bytes())
+	}
+	return nil
+}
+
+// cborArgument reads the argument of a head with additional info ai. It
+// reports false for the indefinite length marker.
+func cborArgument(r *binaryReader, ai byte) (uint64, bool, error) {
+	switch {
+	case ai < 24:
+		return uint64(ai), true, nil
+	case ai <= 27:
+		n, err := r.uint(1 << (ai - 24))
+		return n, true, err
+	case ai == 31:
+		return 0, false, nil
+	}
+	r.pos--
+	return 0, false, r.errorf("invalid additional info %d", ai)
+}
+
+func decodeCBOR(r *binaryReader) (interface{}, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	var major, ai = c & 0xe0, c & 0x1f
+	if major == cborSimple {
+		return cborSimpleValue(r, ai)
+	}
+	n, definite, err := cborArgument(r, ai)
+	if err != nil {
+		return nil, err
+	}
+	if !definite && (major == cborUint || major == cborNegint || major == cborTag) {
+		r.pos--
+		return nil, r.errorf("invalid additional info %d", ai)
+	}
+
+	switch major {
+	case cborUint:
+		return json.Number(strconv.FormatUint(n, 10)), nil
+	case cborNegint:
+		var i = new(big.Int).SetUint64(n)
+		return json.Number(i.Neg(i).Sub(i, big.NewInt(1)).String()), nil
+	case cborBytes, cborText:
+		b, err := cborString(r, major, n, definite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return b, nil
+	case cborArray:
+		return cborArrayValue(r, n, definite)
+	case cborMap:
+		return cborMapValue(r, n, definite)
+	}
+	return cborTagged(r, n)
+}
+
+func cborSimpleValue(r *binaryReader, ai byte) (interface{}, error) {
+	switch ai {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		u, err := r.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(float16(uint16(u)))
+	case 26:
+		u, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(float64(math.Float32frombits(uint32(u))))
+	case 27:
+		u, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(math.Float64frombits(u))
+	case 31:
+		return cborBreak, nil
+	}
+	r.pos--
+	return nil, r.errorf("unsupported simple value %d", ai)
+}
+
+// toFloat16 returns the half-precision form of f if it holds f exactly.
+func toFloat16(f float64) (uint16, bool) {
+	if !isFloat32(f) {
+		return 0, false
+	}
+	var bits = math.Float32bits(float32(f))
+	var sign = uint16(bits>>16) & 0x8000
+	var exp, mant = int(bits>>23&0xff) - 127, bits & 0x7fffff
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign, true
+	case exp >= -14 && exp <= 15 && mant&0x1fff == 0:
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		var shift = uint(13 - 14 - exp)
+		if mant |= 0x800000; mant&(1<<shift-1) == 0 {
+			return sign | uint16(mant>>shift), true
+		}
+	}
+	return 0, false
+}
+
+// float16 expands an IEEE 754 half-precision float.
+func float16(h uint16) float64 {
+	var sign = 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	var exp, frac = int(h>>10) & 0x1f, float64(h & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac != 0 {
+			return math.NaN()
+		}
+		return math.Inf(int(sign))
+	}
+	return sign * math.Ldexp(frac+1024, exp-25)
+}
+
+// cborString reads a byte or text string, joining the chunks of an
+// indefinite length one.
+func cborString(r *binaryReader, major byte, n uint64, definite bool) ([]byte, error) {
+	if definite {
+		b, err := r.next(n)
+		return append([]byte{}, b...), err
+	}
+	var buf []byte
+	for {
+		c, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if c == cborSimple|31 {
+			return buf, nil
+		}
+		if c&0xe0 != major {
+			r.pos--
+			return nil, r.errorf("invalid chunk in indefinite length string")
+		}
+		n, definite, err := cborArgument(r, c&0x1f)
+		if err != nil {
+			return nil, err
+		}
+		if !definite {
+			r.pos--
+			return nil, r.errorf("nested indefinite length string")
+		}
+		b, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, b...)
+	}
+}
+
+func cborArrayValue(r *binaryReader, n uint64, definite bool) (interface{}, error) {
+	var arr []interface{}
+	if definite {
+		length, err := r.items(n, 1)
+		if err != nil {
+			return nil, err
+		}
+		arr = make([]interface{}, 0, length)
+	} else {
+		arr = []interface{}{}
+	}
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	for i := uint64(0); !definite || i < n; i++ {
+		elem, err := decodeCBOR(r)
+		if err != nil {
+			return nil, err
+		}
+		if elem == cborBreak {
+			if definite {
+				return nil, r.errorf("unexpected break")
+			}
+			break
+		}
+		arr = append(arr, elem)
+	}
+	return arr, nil
+}
+
+func cborMapValue(r *binaryReader, n uint64, definite bool) (interface{}, error) {
+	if definite {
+		if _, err := r.items(n, 2); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	var obj = make(map[string]interface{})
+	for i := uint64(0); !definite || i < n; i++ {
+		var offset = r.pos
+		key, err := decodeCBOR(r)
+		if err != nil {
+			return nil, err
+		}
+		if key == cborBreak && !definite {
+			break
+		}
+		str, ok := key.(string)
+		if !ok {
+			r.pos = offset
+			return nil, r.errorf("map key is not a string")
+		}
+		val, err := decodeCBOR(r)
+		if err != nil {
+			return nil, err
+		}
+		if val == cborBreak {
+			return nil, r.errorf("unexpected break")
+		}
+		obj[str] = val
+	}
+	return obj, nil
+}
+
+func cborTagged(r *binaryReader, tag uint64) (interface{}, error) {
+	var offset = r.pos
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	content, err := decodeCBOR(r)
+	if err != nil {
+		return nil, err
+	}
+	if content == cborBreak {
+		return nil, r.errorf("unexpected break")
+	}
+
+	switch tag {
+	case 1:
+		num, ok := content.(json.Number)
+		if !ok {
+			break
+		}
+		f, err := num.Float64()
+		if err != nil {
+			break
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format(TimeLayout), nil
+	case 2, 3:
+		b, ok := content.([]byte)
+		if !ok {
+			r.pos = offset
+			return nil, r.errorf("bignum content is not a byte string")
+		}
+		var i = new(big.Int).SetBytes(b)
+		if tag == 3 {
+			i.Neg(i).Sub(i, big.NewInt(1))
+		}
+		return json.Number(i.String()), nil
+	case 4:
+		// Decimal fraction [exponent, mantissa].
+		arr, ok := content.([]interface{})
+		if ok && len(arr) == 2 {
+			exp, ok1 := arr[0].(json.Number)
+			mant, ok2 := arr[1].(json.Number)
+			if ok1 && ok2 {
+				return json.Number(string(mant) + "e" + string(exp)), nil
+			}
+		}
+		r.pos = offset
+		return nil, r.errorf("invalid decimal fraction")
+	}
+	return content, nil
+}
@@ -0,0 +1,83 @@
+package jsons
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_MarshalCBOR(t *testing.T) {
+	// Examples from RFC 8949 appendix A.
+	for _, c := range []struct {
+		json string
+		hex  string
+	}{
+		{`0`, "00"},
+		{`1000000`, "1a000f4240"},
+		{`18446744073709551615`, "1bffffffffffffffff"},
+		{`18446744073709551616`, "c249010000000000000000"},
+		{`-18446744073709551616`, "3bffffffffffffffff"},
+		{`-18446744073709551617`, "c349010000000000000000"},
+		{`-1000`, "3903e7"},
+		{`1.5`, "f93e00"},
+		{`65504.0`, "f97bff"},
+		{`100000.0`, "fa47c35000"},
+		{`1.1`, "fb3ff199999999999a"},
+		{`5.960464477539063e-08`, "f90001"},
+		{`-4.0`, "f9c400"},
+		{`null`, "f6"},
+		{`"IETF"`, "6449455446"},
+		{`[1, [2, 3]]`, "8201820203"},
+		{`{"aa": 1, "b": 2}`, "a2616202626161" + "01"},
+	} {
+		val, _ := Unmarshal([]byte(c.json))
+		data, err := val.MarshalCBOR()
+		assert.NoError(t, err, c.json)
+		assert.Equal(t, hex.EncodeToString(data), c.hex, c.json)
+
+		var back Value
+		assert.NoError(t, back.UnmarshalCBOR(data), c.json)
+		assert.Equal(t, back.JSONString(), val.JSONString(), c.json)
+	}
+
+	_, err := value(Number("Infinity")).MarshalCBOR()
+	assert.Error(t, err)
+}
+
+func TestValue_UnmarshalCBOR(t *testing.T) {
+	var val Value
+	for _, c := range []struct {
+		hex string
+		num Number
+	}{
+		{"f90400", "6.103515625e-05"},
+		{"f93c00", "1.0"},
+		{"c48221196ab3", "27315e-2"},
+	} {
+		data, _ := hex.DecodeString(c.hex)
+		assert.NoError(t, val.UnmarshalCBOR(data), c.hex)
+		assert.Equal(t, val.Number(), c.num, c.hex)
+	}
+
+	for _, c := range []struct {
+		hex  string
+		json string
+	}{
+		{"5f42010243030405ff", `"AQIDBAU="`},
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		{"9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"c11a514b67b0", `"2013-03-21T20:04:00Z"`},
+		{"d82076687474703a2f2f7777772e6578616d706c652e636f6d", `"http://www.example.com"`},
+	} {
+		data, _ := hex.DecodeString(c.hex)
+		assert.NoError(t, val.UnmarshalCBOR(data), c.hex)
+		assert.Equal(t, val.JSONString(), c.json, c.hex)
+	}
+
+	for _, s := range []string{"", "1c", "ff", "a10102", "5f01ff", "8201", "0000", "c243", "f97e00", "fa7f800000", "81fbfff0000000000000"} {
+		data, _ := hex.DecodeString(s)
+		assert.Error(t, val.UnmarshalCBOR(data), s)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/zooyer/jsons"
+)
+
+// formats are the names convert accepts, which are also the file
+// extensions it guesses -from by.
+var formats = []string{"json", "jsonc", "json5", "yaml", "toml", "xml", "csv", "tsv", "msgpack", "cbor", "bson"}
+
+// guessFormat returns the format named by the extension of a file, or json.
+func guessFormat(name string) string {
+	var ext = strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	switch ext {
+	case "yml":
+		return "yaml"
+	case "mp":
+		return "msgpack"
+	}
+	if knownFormat(ext) {
+		return ext
+	}
+	return "json"
+}
+
+func knownFormat(name string) bool {
+	for _, f := range formats {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// decode returns the documents of data, several only for a YAML stream.
+func decode(format string, data []byte) ([]jsons.Value, error) {
+	var val jsons.Value
+	var err error
+	switch format {
+	case "json":
+		val, err = jsons.Unmarshal(data)
+	case "jsonc":
+		val, err = jsons.UnmarshalJSONC(data)
+	case "json5":
+		val, err = jsons.UnmarshalJSON5(data)
+	case "yaml":
+		docs, err := jsons.FromYAMLAll(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			return []jsons.Value{{}}, nil
+		}
+		var vals = make([]jsons.Value, len(docs))
+		for i := range docs {
+			vals[i] = docs.Get(i)
+		}
+		return vals, nil
+	case "toml":
+		val, err = jsons.FromTOML(data)
+	case "xml":
+		val, err = jsons.FromXML(data)
+	case "csv", "tsv":
+		var arr jsons.Array
+		if arr, err = jsons.FromCSV(data, csvOptions(format)); err == nil {
+			err = val.Unmarshal(arr)
+		}
+	case "msgpack":
+		err = val.UnmarshalMsgpack(data)
+	case "cbor":
+		err = val.UnmarshalCBOR(data)
+	case "bson":
+		val, err = jsons.UnmarshalBSON(data, jsons.ExtJSONRelaxed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []jsons.Value{val}, nil
+}
+
+func encode(format string, val jsons.Value, root string, compact bool) ([]byte, error) {
+	var data []byte
+	var err error
+	switch format {
+	case "json", "jsonc", "json5":
+		var opts = jsons.EncodeOptions{Indent: "  "}
+		if compact {
+			opts.Indent = ""
+		}
+		if data, err = jsons.MarshalWithOptions(val, opts); err == nil {
+			data = append(data, '\n')
+		}
+	case "yaml":
+		data, err = val.YAML()
+	case "toml":
+		data, err = val.TOML()
+	case "xml":
+		data, err = val.XML(root)
+	case "csv", "tsv":
+		if !val.IsArray() {
+			return nil, fmt.Errorf("%s needs an array of objects", format)
+		}
+		data, err = jsons.ToCSV(val.Array(), csvOptions(format))
+	case "msgpack":
+		data, err = val.MarshalMsgpack()
+	case "cbor":
+		data, err = val.MarshalCBOR()
+	case "bson":
+		data, err = val.MarshalBSON()
+	}
+	return data, err
+}
+
+func csvOptions(format string) jsons.CSVOptions {
+	if format == "tsv" {
+		return jsons.CSVOptions{Comma: '\t'}
+	}
+	return jsons.CSVOptions{}
+}
+
+func runConvert(e env, args []string) error {
+	var fs = flags(e, "convert")
+	var from = fs.String("from", "", "input format, by default guessed from the file name: "+strings.Join(formats, ", "))
+	var to = fs.String("to", "json", "output format")
+	var root = fs.String("root", "root", "root element name for xml output")
+	var compact = fs.Bool("c", false, "print compact JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 0, 1)
+	if err != nil {
+		return err
+	}
+	if *from == "" {
+		*from = guessFormat(ops[0])
+	}
+	for _, f := range []*string{from, to} {
+		if *f = strings.ToLower(*f); !knownFormat(*f) {
+			return fmt.Errorf("unknown format %q, want one of %s", *f, strings.Join(formats, ", "))
+		}
+	}
+	data, err := readFile(e, ops[0])
+	if err != nil {
+		return err
+	}
+	vals, err := decode(*from, data)
+	if err != nil {
+		return err
+	}
+	if len(vals) != 1 && !streams(*to) {
+		return fmt.Errorf("input holds %d documents, which %s cannot hold", len(vals), *to)
+	}
+	for i, val := range vals {
+		if data, err = encode(*to, val, *root, *compact); err != nil {
+			return err
+		}
+		if i > 0 && *to == "yaml" {
+			data = append([]byte("---\n"), data...)
+		}
+		if _, err = e.stdout.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streams reports whether a format can hold several documents one after
+// another, as a YAML stream or concatenated JSON values.
+func streams(format string) bool {
+	switch format {
+	case "json", "jsonc", "json5", "yaml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"github.com/zooyer/jsons"
+)
+
+func runDiff(e env, args []string) error {
+	var fs = flags(e, "diff")
+	var compact = fs.Bool("c", false, "print compact JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 2, 2)
+	if err != nil {
+		return err
+	}
+	a, err := readValue(e, ops[0])
+	if err != nil {
+		return err
+	}
+	b, err := readValue(e, ops[1])
+	if err != nil {
+		return err
+	}
+	patch, err := jsons.Diff(a, b)
+	if err != nil {
+		return err
+	}
+	if err = write(e, patch, *compact); err != nil {
+		return err
+	}
+	if len(patch) > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+func runPatch(e env, args []string) error {
+	var fs = flags(e, "patch")
+	var compact = fs.Bool("c", false, "print compact JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 1, 2)
+	if err != nil {
+		return err
+	}
+	patch, err := readValue(e, ops[0])
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(e, ops[1])
+	if err != nil {
+		return err
+	}
+	if err = doc.Patch(patch); err != nil {
+		return err
+	}
+	return writeEdited(e, doc, *compact)
+}
+
+func runMerge(e env, args []string) error {
+	var fs = flags(e, "merge")
+	var compact = fs.Bool("c", false, "print compact JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 2, -1)
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(e, ops[0])
+	if err != nil {
+		return err
+	}
+	for _, name := range ops[1:] {
+		patch, err := readValue(e, name)
+		if err != nil {
+			return err
+		}
+		if err = doc.MergePatch(patch); err != nil {
+			return err
+		}
+	}
+	return writeEdited(e, doc, *compact)
+}
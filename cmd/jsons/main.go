@@ -0,0 +1,415 @@
+// Command jsons reads, edits, queries and converts JSON documents with the
+// same semantics as the jsons package, numbers keeping their exact spelling.
+//
+// Usage:
+//
+//	jsons get [-c] <path> [file]
+//	jsons set <path> <json> [file]
+//	jsons del <path> [file]
+//	jsons query [-c] <jsonpath or jq filter> [file]
+//	jsons fmt [-indent s] [-sort] [file]
+//	jsons compact [file]
+//	jsons diff <a> <b>
+//	jsons patch <patch> [file]
+//	jsons merge <file> <patch>...
+//	jsons validate -schema <schema> [file]
+//	jsons convert [-from format] [-to format] [file]
+//
+// Paths are written as a.b[0].c, optionally starting with $, and
+// ["key"] quotes keys holding dots or brackets. A missing or "-" file reads
+// standard input. fmt, compact and the -c of patch and merge take strict
+// JSON only, as reformatting would drop comments. diff and validate exit
+// with status 1 when the documents differ or are invalid, and every command
+// exits with 2 on errors.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zooyer/jsons"
+)
+
+// errFailed reports a negative result, such as a difference, that has been
+// printed already.
+var errFailed = errors.New("failed")
+
+// errJSONC refuses to reformat a document with comments or trailing commas.
+var errJSONC = errors.New("input has comments or trailing commas, which reformatting would drop")
+
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(e env, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"get":      {"get [-c] <path> [file]", runGet},
+		"set":      {"set <path> <json> [file]", runSet},
+		"del":      {"del <path> [file]", runDel},
+		"query":    {"query [-c] <jsonpath or jq filter> [file]", runQuery},
+		"fmt":      {"fmt [-indent s] [-sort] [file]", runFmt},
+		"compact":  {"compact [file]", runCompact},
+		"diff":     {"diff <a> <b>", runDiff},
+		"patch":    {"patch <patch> [file]", runPatch},
+		"merge":    {"merge <file> <patch>...", runMerge},
+		"validate": {"validate -schema <schema> [file]", runValidate},
+		"convert":  {"convert [-from format] [-to format] [file]", runConvert},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], env{os.Stdin, os.Stdout, os.Stderr}))
+}
+
+func run(args []string, e env) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(e.stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "jsons: unknown command %q\n", args[0])
+		usage(e.stderr)
+		return 2
+	}
+	switch err := cmd.run(e, args[1:]); {
+	case err == errFailed:
+		return 1
+	case err == flag.ErrHelp:
+		return 2
+	case err != nil:
+		fmt.Fprintf(e.stderr, "jsons %s: %s\n", args[0], strings.TrimPrefix(err.Error(), "jsons: "))
+		return 2
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	var names = make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "usage:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  jsons %s\n", commands[name].usage)
+	}
+}
+
+// flags returns a flag set for a command that prints its usage on errors.
+func flags(e env, name string) *flag.FlagSet {
+	var fs = flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: jsons %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// operands checks the number of positional arguments, the last optional
+// ones defaulting to "-".
+func operands(fs *flag.FlagSet, min, max int) ([]string, error) {
+	var args = fs.Args()
+	if len(args) < min || max >= 0 && len(args) > max {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	for len(args) < max {
+		args = append(args, "-")
+	}
+	return args, nil
+}
+
+func readFile(e env, name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// readValue reads a JSON document, accepting comments and trailing commas.
+func readValue(e env, name string) (jsons.Value, error) {
+	data, err := readFile(e, name)
+	if err != nil {
+		return jsons.Value{}, err
+	}
+	if val, err := jsons.Unmarshal(data); err == nil {
+		return val, nil
+	}
+	val, err := jsons.UnmarshalJSONC(data)
+	if err != nil && name != "-" {
+		return val, fmt.Errorf("%s: %v", name, err)
+	}
+	return val, err
+}
+
+// readDocument reads a JSON or JSONC document to edit in place.
+func readDocument(e env, name string) (*jsons.Document, error) {
+	data, err := readFile(e, name)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsons.ParseDocument(data)
+	if err != nil && name != "-" {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return doc, err
+}
+
+func write(e env, v interface{}, compact bool) error {
+	var opts = jsons.EncodeOptions{Indent: "  "}
+	if compact {
+		opts.Indent = ""
+	}
+	data, err := jsons.MarshalWithOptions(v, opts)
+	if err != nil {
+		return err
+	}
+	_, err = e.stdout.Write(append(data, '\n'))
+	return err
+}
+
+// parsePath splits a path such as $.a.b[0]["c.d"] into keys.
+func parsePath(s string) ([]interface{}, error) {
+	var keys = make([]interface{}, 0)
+	var rest = strings.TrimPrefix(strings.TrimSpace(s), "$")
+	if rest == "." {
+		return keys, nil
+	}
+	for first := true; rest != ""; first = false {
+		switch {
+		case strings.HasPrefix(rest, `["`) || strings.HasPrefix(rest, "['"):
+			n, err := quotedEnd(rest[1:])
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %v", s, err)
+			}
+			var key = rest[2:n]
+			if rest[1] == '"' {
+				if key, err = strconv.Unquote(rest[1 : 1+n]); err != nil {
+					return nil, fmt.Errorf("path %q has an invalid key", s)
+				}
+			}
+			if !strings.HasPrefix(rest[1+n:], "]") {
+				return nil, fmt.Errorf("path %q has an unclosed bracket", s)
+			}
+			keys = append(keys, key)
+			rest = rest[2+n:]
+		case rest[0] == '[':
+			var end = strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unclosed bracket", s)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("path %q has an invalid index %q", s, rest[1:end])
+			}
+			keys = append(keys, i)
+			rest = rest[end+1:]
+		case rest[0] == '.' || first:
+			if rest[0] == '.' {
+				rest = rest[1:]
+			}
+			var end = strings.IndexAny(rest, ".[]")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %q has an empty key", s)
+			}
+			keys = append(keys, rest[:end])
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("path %q is invalid at %q", s, rest)
+		}
+	}
+	return keys, nil
+}
+
+// quotedEnd returns the length of the quoted string s starts with, where
+// only double quotes allow escapes.
+func quotedEnd(s string) (int, error) {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && s[0] == '"':
+			i++
+		case s[i] == s[0]:
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated key")
+}
+
+func runGet(e env, args []string) error {
+	var fs = flags(e, "get")
+	var compact = fs.Bool("c", false, "print compact JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 1, 2)
+	if err != nil {
+		return err
+	}
+	keys, err := parsePath(ops[0])
+	if err != nil {
+		return err
+	}
+	data, err := readFile(e, ops[1])
+	if err != nil {
+		return err
+	}
+	doc, err := jsons.ParseDocument(data)
+	if err != nil {
+		return err
+	}
+	if !doc.Exist(keys...) {
+		return fmt.Errorf("%s: %v", jsons.JSONPath(keys...), jsons.ErrNotFound)
+	}
+	if raw := jsons.Raw(data); raw.IsValid() {
+		return write(e, raw.Get(keys...), *compact)
+	}
+	val, err := doc.Value(keys...)
+	if err != nil {
+		return err
+	}
+	return write(e, val, *compact)
+}
+
+func runSet(e env, args []string) error {
+	var fs = flags(e, "set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 2, 3)
+	if err != nil {
+		return err
+	}
+	keys, err := parsePath(ops[0])
+	if err != nil {
+		return err
+	}
+	val, err := jsons.Unmarshal([]byte(ops[1]))
+	if err != nil {
+		return fmt.Errorf("invalid JSON value %q", ops[1])
+	}
+	if len(keys) == 0 {
+		return write(e, val, false)
+	}
+	doc, err := readDocument(e, ops[2])
+	if err != nil {
+		return err
+	}
+	if err = doc.Set(append(keys, val)...); err != nil {
+		return err
+	}
+	return writeDocument(e, doc)
+}
+
+func runDel(e env, args []string) error {
+	var fs = flags(e, "del")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 1, 2)
+	if err != nil {
+		return err
+	}
+	keys, err := parsePath(ops[0])
+	if err != nil {
+		return err
+	}
+	doc, err := readDocument(e, ops[1])
+	if err != nil {
+		return err
+	}
+	if err = doc.Delete(keys...); err != nil {
+		return err
+	}
+	return writeDocument(e, doc)
+}
+
+// writeEdited prints an edited document as writeDocument does, or
+// compacted.
+func writeEdited(e env, doc *jsons.Document, compact bool) error {
+	if compact {
+		return reformat(e, doc.Bytes(), jsons.EncodeOptions{})
+	}
+	return writeDocument(e, doc)
+}
+
+// writeDocument prints an edited document as it is, keeping its layout.
+func writeDocument(e env, doc *jsons.Document) error {
+	var data = doc.Bytes()
+	if !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	_, err := e.stdout.Write(data)
+	return err
+}
+
+func runFmt(e env, args []string) error {
+	var fs = flags(e, "fmt")
+	var indent = fs.String("indent", "  ", "indentation of nested values")
+	var sortKeys = fs.Bool("sort", false, "sort object keys")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 0, 1)
+	if err != nil {
+		return err
+	}
+	return format(e, ops[0], jsons.EncodeOptions{Indent: *indent, SortKeys: *sortKeys})
+}
+
+func runCompact(e env, args []string) error {
+	var fs = flags(e, "compact")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 0, 1)
+	if err != nil {
+		return err
+	}
+	return format(e, ops[0], jsons.EncodeOptions{})
+}
+
+// format reprints a document keeping its key order.
+func format(e env, name string, opts jsons.EncodeOptions) error {
+	data, err := readFile(e, name)
+	if err != nil {
+		return err
+	}
+	return reformat(e, data, opts)
+}
+
+// reformat reprints strict JSON. JSONC is refused rather than losing its
+// comments, which no reformatting can place.
+func reformat(e env, data []byte, opts jsons.EncodeOptions) error {
+	if !jsons.Raw(data).IsValid() {
+		if _, err := jsons.UnmarshalJSONC(data); err != nil {
+			return err
+		}
+		return errJSONC
+	}
+	out, err := jsons.MarshalWithOptions(jsons.Raw(data), opts)
+	if err != nil {
+		return err
+	}
+	_, err = e.stdout.Write(append(out, '\n'))
+	return err
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func runJSONS(t *testing.T, stdin string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	var code = run(args, env{strings.NewReader(stdin), &stdout, &stderr})
+	return stdout.String(), stderr.String(), code
+}
+
+func tempFile(t *testing.T, name, data string) string {
+	var path = filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	return path
+}
+
+func TestParsePath(t *testing.T) {
+	var tests = []struct {
+		path string
+		keys []interface{}
+	}{
+		{"$", []interface{}{}},
+		{".", []interface{}{}},
+		{"a.b[0]", []interface{}{"a", "b", 0}},
+		{"$.a[1][2]", []interface{}{"a", 1, 2}},
+		{`a["x.y"].c`, []interface{}{"a", "x.y", "c"}},
+		{`['x[0]']`, []interface{}{"x[0]"}},
+		{`["a\"]"]`, []interface{}{`a"]`}},
+	}
+	for _, test := range tests {
+		keys, err := parsePath(test.path)
+		assert.NoError(t, err, test.path)
+		assert.Equal(t, keys, test.keys, test.path)
+	}
+	for _, path := range []string{"a..b", "a[", "a[-1]", "a[x]", "a]"} {
+		_, err := parsePath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestGetSetDel(t *testing.T) {
+	const doc = `{"b": 1.50, "a": [1, {"x.y": 100000000000000000000001}]}`
+	out, _, code := runJSONS(t, doc, "get", `a[1]["x.y"]`)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, "100000000000000000000001\n")
+
+	out, _, code = runJSONS(t, doc, "get", "-c", "$")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, `{"b":1.50,"a":[1,{"x.y":100000000000000000000001}]}`+"\n")
+
+	_, errOut, code := runJSONS(t, doc, "get", "a[5]")
+	assert.Equal(t, code, 2)
+	assert.Equal(t, errOut, "jsons get: $.a[5]: path not found\n")
+
+	var file = tempFile(t, "doc.json", "{\n  // keep\n  \"b\": 1.50\n}\n")
+	out, _, code = runJSONS(t, "", "set", "c", `{"d":[true]}`, file)
+	assert.Equal(t, code, 0)
+	assert.Contains(t, out, "// keep")
+	assert.Contains(t, out, `"b": 1.50`)
+	out, _, _ = runJSONS(t, out, "get", "-c", "c")
+	assert.Equal(t, out, `{"d":[true]}`+"\n")
+
+	out, _, code = runJSONS(t, doc, "del", "a[0]")
+	assert.Equal(t, code, 0)
+	out, _, _ = runJSONS(t, out, "compact")
+	assert.Equal(t, out, `{"b":1.50,"a":[{"x.y":100000000000000000000001}]}`+"\n")
+
+	_, _, code = runJSONS(t, doc, "set", "b", "not json")
+	assert.Equal(t, code, 2)
+}
+
+func TestFmt(t *testing.T) {
+	out, _, code := runJSONS(t, `{"b":1.0,"a":[]}`, "fmt")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, "{\n  \"b\": 1.0,\n  \"a\": []\n}\n")
+
+	out, _, _ = runJSONS(t, `{"b":1.0,"a":[]}`, "fmt", "-sort", "-indent", "\t")
+	assert.Equal(t, out, "{\n\t\"a\": [],\n\t\"b\": 1.0\n}\n")
+
+	out, errOut, code := runJSONS(t, "{\n  \"a\": 1, // one\n}", "compact")
+	assert.Equal(t, code, 2)
+	assert.Equal(t, out, "")
+	assert.Equal(t, errOut, "jsons compact: input has comments or trailing commas, which reformatting would drop\n")
+
+	_, errOut, code = runJSONS(t, "{\"a\": 1,,}", "fmt")
+	assert.Equal(t, code, 2)
+	assert.NotContains(t, errOut, "comments")
+}
+
+func TestQuery(t *testing.T) {
+	const doc = `{"items": [{"id": 1, "price": 0.10}, {"id": 2, "price": 0.20}, {"name": "x"}]}`
+	var tests = []struct {
+		query string
+		out   string
+	}{
+		{"$.items[*].price", "0.10\n0.20\n"},
+		{"$..id", "1\n2\n"},
+		{"$.items[-1].name", "\"x\"\n"},
+		{"$.items[5]", ""},
+		{"$.items[0:2].id", "1\n2\n"},
+		{"$['items'][1]['price']", "0.20\n"},
+		{"[.items[].price] | add", "0.3\n"},
+	}
+	for _, test := range tests {
+		out, errOut, code := runJSONS(t, doc, "query", "-c", test.query)
+		assert.Equal(t, code, 0, test.query+errOut)
+		assert.Equal(t, out, test.out, test.query)
+	}
+
+	_, errOut, code := runJSONS(t, doc, "query", "$.items[?(@.id)]")
+	assert.Equal(t, code, 2)
+	assert.Contains(t, errOut, "filter expressions are not supported")
+}
+
+func TestDiffPatch(t *testing.T) {
+	var a = tempFile(t, "a.json", `{"a": [1, 2, 3], "b": {"c": 1.0}, "d": "x"}`)
+	var b = tempFile(t, "b.json", `{"a": [1, 4], "b": {"c": 1}, "e/f": null}`)
+	out, _, code := runJSONS(t, "", "diff", "-c", a, b)
+	assert.Equal(t, code, 1)
+	assert.Equal(t, out, `[{"op":"replace","path":"/a/1","value":4},{"op":"remove","path":"/a/2"},{"op":"remove","path":"/d"},{"op":"add","path":"/e~1f","value":null}]`+"\n")
+
+	var patch = tempFile(t, "patch.json", out)
+	out, _, code = runJSONS(t, "", "patch", "-c", patch, a)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, `{"a":[1,4],"b":{"c":1.0},"e/f":null}`+"\n")
+
+	out, _, code = runJSONS(t, "", "diff", a, a)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, "[]\n")
+
+	// Patching keeps the key order and layout of the document.
+	patch = tempFile(t, "p.json", `[{"op":"add","path":"/m","value":1},{"op":"replace","path":"/a","value":2}]`)
+	out, _, code = runJSONS(t, "{\n  \"z\": 1, // first\n  \"a\": 1\n}", "patch", patch)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, "{\n  \"z\": 1, // first\n  \"a\": 2,\n  \"m\": 1\n}\n")
+
+	_, errOut, code := runJSONS(t, `{"a":[1,2]}`, "patch", tempFile(t, "p.json", `[{"op":"remove","path":"/x"}]`))
+	assert.Equal(t, code, 2)
+	assert.Equal(t, errOut, "jsons patch: patch operation 0: /x: path not found\n")
+}
+
+func TestMerge(t *testing.T) {
+	var p1 = tempFile(t, "p1.json", `{"a": {"b": null, "c": 1.50}, "d": [1]}`)
+	var p2 = tempFile(t, "p2.json", `{"d": null, "e": "x"}`)
+	out, _, code := runJSONS(t, `{"z": 0, "a": {"b": 1}, "d": 2}`, "merge", "-c", "-", p1, p2)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, `{"z":0,"a":{"c":1.50},"e":"x"}`+"\n")
+
+	out, _, _ = runJSONS(t, `{"z": 0, "a": 1}`, "merge", "-", p2)
+	assert.Equal(t, out, `{"z": 0, "a": 1, "e": "x"}`+"\n")
+}
+
+func TestValidate(t *testing.T) {
+	var schema = tempFile(t, "schema.json", `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"id": {"$ref": "#/$defs/id"}},
+		"$defs": {"id": {"type": "integer", "minimum": 1}}
+	}`)
+	out, _, code := runJSONS(t, `{"id": 1.0}`, "validate", "-schema", schema)
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, "")
+
+	out, _, code = runJSONS(t, `{"id": 0.5}`, "validate", "-schema", schema)
+	assert.Equal(t, code, 1)
+	assert.Equal(t, out, "$.id: expected integer, got number\n$.id: 0.5 is less than 1\n")
+
+	_, errOut, code := runJSONS(t, `{}`, "validate", "-schema", tempFile(t, "s.json", `{"$ref": "#/nope"}`))
+	assert.Equal(t, code, 2)
+	assert.Contains(t, errOut, `$ref "#/nope"`)
+
+	_, _, code = runJSONS(t, `{}`, "validate")
+	assert.Equal(t, code, 2)
+}
+
+func TestConvert(t *testing.T) {
+	out, _, code := runJSONS(t, "a: 1.50\nb: [x, true]\n", "convert", "-from", "yaml", "-c")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, `{"a":1.50,"b":["x",true]}`+"\n")
+
+	out, _, code = runJSONS(t, "a: 1\n---\nb: 2\n", "convert", "-from", "yaml", "-c")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, `{"a":1}`+"\n"+`{"b":2}`+"\n")
+	out, _, _ = runJSONS(t, "a: 1\n---\nb: 2\n", "convert", "-from", "yaml", "-to", "yaml")
+	assert.Equal(t, out, "a: 1\n---\nb: 2\n")
+	_, errOut, code := runJSONS(t, "a: 1\n---\nb: 2\n", "convert", "-from", "yaml", "-to", "toml")
+	assert.Equal(t, code, 2)
+	assert.Contains(t, errOut, "2 documents")
+
+	var file = tempFile(t, "rows.csv", "id,user.name\n1,ann\n")
+	out, _, _ = runJSONS(t, "", "convert", "-c", file)
+	assert.Equal(t, out, `[{"id":1,"user":{"name":"ann"}}]`+"\n")
+
+	out, _, _ = runJSONS(t, `{"a": [1.5, 100000000000000000000001]}`, "convert", "-to", "cbor")
+	out, _, code = runJSONS(t, out, "convert", "-from", "cbor", "-c")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, out, `{"a":[1.5,100000000000000000000001]}`+"\n")
+
+	_, _, code = runJSONS(t, "", "convert", "-to", "ini")
+	assert.Equal(t, code, 2)
+}
+
+func TestUsage(t *testing.T) {
+	_, errOut, code := runJSONS(t, "", "frob")
+	assert.Equal(t, code, 2)
+	assert.Contains(t, errOut, `unknown command "frob"`)
+
+	_, errOut, code = runJSONS(t, "", "get")
+	assert.Equal(t, code, 2)
+	assert.Contains(t, errOut, "usage: jsons get")
+}
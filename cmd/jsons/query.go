@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zooyer/jsons"
+)
+
+// jsonPathToJQ translates a JSONPath expression, starting with $, to a jq
+// filter emitting the same matches: names such as .a or ['a'], indexes
+// [0] and [-1], slices [1:3], wildcards .* and [*], and descent with ..
+// followed by one of these. Filter expressions are not supported; write
+// them as jq filters instead.
+func jsonPathToJQ(path string) (string, error) {
+	var steps = []string{"."}
+	var rest = strings.TrimSpace(strings.TrimPrefix(path, "$"))
+	var fail = func(format string, args ...interface{}) (string, error) {
+		return "", fmt.Errorf("JSONPath %q: %s", path, fmt.Sprintf(format, args...))
+	}
+	for rest != "" {
+		var descend = strings.HasPrefix(rest, "..")
+		switch {
+		case descend:
+			rest = rest[2:]
+			steps = append(steps, "..")
+			if rest == "" || rest[0] != '[' && rest[0] != '*' && !isNameStart(rest[0]) {
+				return fail("nothing follows ..")
+			}
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] != '[':
+			return fail("unexpected %q", rest)
+		}
+		if rest == "" {
+			return fail("missing name after .")
+		}
+
+		var step string
+		switch {
+		case rest[0] == '*':
+			step, rest = "select(type == \"object\" or type == \"array\") | .[]", rest[1:]
+		case rest[0] == '[':
+			var end = strings.IndexByte(rest, ']')
+			if end < 0 {
+				return fail("unclosed bracket")
+			}
+			var inner = strings.TrimSpace(rest[1:end])
+			switch {
+			case inner == "*":
+				step = "select(type == \"object\" or type == \"array\") | .[]"
+			case strings.HasPrefix(inner, "?"):
+				return fail("filter expressions are not supported, use a jq filter")
+			case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+				var name, err = quotedName(inner)
+				if err != nil {
+					return fail("%v", err)
+				}
+				step = nameStep(name)
+			case strings.Contains(inner, ":"):
+				var parts = strings.Split(inner, ":")
+				if len(parts) != 2 || !isInt(parts[0], true) || !isInt(parts[1], true) {
+					return fail("invalid slice [%s]", inner)
+				}
+				step = fmt.Sprintf("select(type == \"array\") | .[%s:%s][]", parts[0], parts[1])
+			case isInt(inner, false):
+				var idx, _ = strconv.Atoi(inner)
+				var need = idx + 1
+				if idx < 0 {
+					need = -idx
+				}
+				step = fmt.Sprintf("select(type == \"array\" and length >= %d) | .[%d]", need, idx)
+			default:
+				return fail("invalid selector [%s]", inner)
+			}
+			rest = rest[end+1:]
+		default:
+			var end = 0
+			for end < len(rest) && rest[end] != '.' && rest[end] != '[' {
+				end++
+			}
+			step, rest = nameStep(rest[:end]), rest[end:]
+		}
+		steps = append(steps, step)
+	}
+	return strings.Join(steps, " | "), nil
+}
+
+func nameStep(name string) string {
+	key, _ := json.Marshal(name)
+	return fmt.Sprintf("select(type == \"object\" and has(%s)) | .[%s]", key, key)
+}
+
+func quotedName(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("unterminated name %s", s)
+	}
+	if s[0] == '"' {
+		return strconv.Unquote(s)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isInt(s string, empty bool) bool {
+	if s == "" {
+		return empty
+	}
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func runQuery(e env, args []string) error {
+	var fs = flags(e, "query")
+	var compact = fs.Bool("c", false, "print compact JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 1, 2)
+	if err != nil {
+		return err
+	}
+	var filter = ops[0]
+	if strings.HasPrefix(strings.TrimSpace(filter), "$") {
+		if filter, err = jsonPathToJQ(filter); err != nil {
+			return err
+		}
+	}
+	q, err := jsons.CompileJQ(filter)
+	if err != nil {
+		return err
+	}
+	val, err := readValue(e, ops[1])
+	if err != nil {
+		return err
+	}
+	results, err := q.Run(val)
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if err = write(e, res, *compact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
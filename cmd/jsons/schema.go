@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/zooyer/jsons"
+)
+
+func runValidate(e env, args []string) error {
+	var fs = flags(e, "validate")
+	var schemaFile = fs.String("schema", "", "JSON Schema file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ops, err := operands(fs, 0, 1)
+	if err != nil {
+		return err
+	}
+	if *schemaFile == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	val, err := readValue(e, *schemaFile)
+	if err != nil {
+		return err
+	}
+	schema, err := jsons.CompileSchema(val)
+	if err != nil {
+		return err
+	}
+	doc, err := readValue(e, ops[0])
+	if err != nil {
+		return err
+	}
+	errs, err := schema.Validate(doc)
+	if err != nil {
+		return err
+	}
+	for _, v := range errs {
+		fmt.Fprintln(e.stdout, v.Error())
+	}
+	if len(errs) > 0 {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSVOptions configures ToCSV and FromCSV. Columns are the leaf paths of a
+// Flattener with Brackets, such as "user.name" or "tags[0]".
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' by default; use '\t' for TSV.
+	Comma rune
+	// Separator joins the keys of a path, "." by default.
+	Separator string
+	// Header fixes the columns and their order. By default ToCSV writes
+	// every path of every row, in the order first seen.
+	Header []string
+	// NullString, if set, is written for null and read back as null. By
+	// default null is written as an empty cell, and empty cells are left
+	// out of the rows FromCSV builds.
+	NullString string
+	// KeepStrings disables reading "true", "false" and JSON numbers as
+	// Bool and Number.
+	KeepStrings bool
+}
+
+func (o CSVOptions) flattener() Flattener {
+	return Flattener{Separator: o.Separator, Brackets: true}
+}
+
+func (o CSVOptions) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+	return o.Comma
+}
+
+// ToCSV writes an Array of Objects as CSV, one row per Object, flattening
+// nested values into columns.
+func ToCSV(arr Array, opts CSVOptions) ([]byte, error) {
+	var f = opts.flattener()
+	var header = opts.Header
+	var seen = make(map[string]bool)
+	var rows = make([]map[string]string, len(arr))
+	for i, elem := range arr {
+		if !value(elem).IsObject() {
+			return nil, fmt.Errorf("jsons: csv: row %d is not an object", i)
+		}
+		var row = make(map[string]string)
+		var err = f.walk("", elem, func(path string, leaf interface{}) {
+			row[path] = opts.cell(leaf)
+			if opts.Header == nil && !seen[path] {
+				seen[path] = true
+				header = append(header, path)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = row
+	}
+
+	var buf bytes.Buffer
+	var w = csv.NewWriter(&buf)
+	w.Comma = opts.comma()
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	var record = make([]string, len(header))
+	for _, row := range rows {
+		for i, path := range header {
+			record[i] = row[path]
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (o CSVOptions) cell(leaf interface{}) string {
+	switch v := leaf.(type) {
+	case nil:
+		return o.NullString
+	case String:
+		return string(v)
+	case Number:
+		return string(v)
+	case Bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case Object:
+		return "{}"
+	case Array:
+		return "[]"
+	}
+	return ""
+}
+
+// FromCSV reads CSV written by ToCSV, or edited since, back into an Array of
+// Objects. The first record is the header of leaf paths.
+func FromCSV(data []byte, opts CSVOptions) (Array, error) {
+	var r = csv.NewReader(bytes.NewReader(data))
+	r.Comma = opts.comma()
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("jsons: csv: header: %v", err)
+	}
+	var f = opts.flattener()
+	for _, path := range header {
+		if path == "" {
+			return nil, fmt.Errorf("jsons: csv: empty column name")
+		}
+		if _, err = f.parse(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var arr = Array{}
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("jsons: csv: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		var flat = make(map[string]interface{})
+		for i, cell := range record {
+			if cell == "" {
+				continue
+			}
+			flat[header[i]] = opts.parse(cell)
+		}
+		root, err := f.rebuild(flat)
+		if err != nil {
+			return nil, fmt.Errorf("jsons: csv: line %d: %s", line, strings.TrimPrefix(err.Error(), "jsons: "))
+		}
+		switch root.(type) {
+		case nil:
+			root = map[string]interface{}{}
+		case map[string]interface{}:
+		default:
+			return nil, fmt.Errorf("jsons: csv: line %d: row is not an object", line)
+		}
+		arr = append(arr, root)
+	}
+	return arr, nil
+}
+
+func (o CSVOptions) parse(cell string) interface{} {
+	switch {
+	case o.NullString != "" && cell == o.NullString:
+		return nil
+	case cell == "{}":
+		return map[string]interface{}{}
+	case cell == "[]":
+		return []interface{}{}
+	case o.KeepStrings:
+		return cell
+	case cell == "true":
+		return true
+	case cell == "false":
+		return false
+	case json.Valid([]byte(cell)) && strings.Trim(cell, "-+.0123456789eE") == "":
+		return json.Number(cell)
+	}
+	return cell
+}
@@ -0,0 +1,44 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestToCSV(t *testing.T) {
+	val, _ := Unmarshal([]byte(`[
+		{"id": 1, "user": {"name": "Ann", "admin": true}, "tags": ["a", "b"], "note": "x, \"y\""},
+		{"id": 2, "user": {"name": "Bob", "email": "b@x.io"}, "zip": "01234", "extra": null, "a.b": {}}
+	]`))
+	data, err := ToCSV(val.Array(), CSVOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `id,note,tags[0],tags[1],user.admin,user.name,a\.b,extra,user.email,zip
+1,"x, ""y""",a,b,true,Ann,,,,
+2,,,,,Bob,{},,b@x.io,01234
+`)
+
+	arr, err := FromCSV(data, CSVOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: arr}.JSONString(), `[{"id":1,"note":"x, \"y\"","tags":["a","b"],"user":{"admin":true,"name":"Ann"}},{"a.b":{},"id":2,"user":{"email":"b@x.io","name":"Bob"},"zip":"01234"}]`)
+
+	data, err = ToCSV(val.Array(), CSVOptions{Comma: '\t', Header: []string{"id", "user.name", "extra"}, NullString: "NULL"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "id\tuser.name\textra\n1\tAnn\t\n2\tBob\tNULL\n")
+
+	arr, err = FromCSV(data, CSVOptions{Comma: '\t', NullString: "NULL", KeepStrings: true})
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: arr}.JSONString(), `[{"id":"1","user":{"name":"Ann"}},{"extra":null,"id":"2","user":{"name":"Bob"}}]`)
+
+	arr, err = FromCSV([]byte("tags[1500]\nx\n"), CSVOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: arr}.Len(0, "tags"), 1501)
+	assert.Equal(t, Value{value: arr}.String(0, "tags", 1500), "x")
+
+	_, err = ToCSV(Array{1}, CSVOptions{})
+	assert.Error(t, err)
+	for _, data := range []string{"", "a,a.b\n1,2\n", "a[x]\n1\n", "a[99999]\n1\n", "a\n1,2\n", "[0]\n1\n"} {
+		_, err = FromCSV([]byte(data), CSVOptions{})
+		assert.Error(t, err, data)
+	}
+}
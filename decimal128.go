@@ -0,0 +1,145 @@
+package jsons
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// IEEE 754-2008 decimal128 in the binary integer decimal encoding BSON uses.
+const (
+	decimalBias        = 6176
+	decimalMinExponent = -6176
+	decimalMaxExponent = 6111
+	decimalMaxDigits   = 34
+)
+
+var decimalMaxCoefficient = new(big.Int).Sub(new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalMaxDigits), nil), big.NewInt(1))
+
+// parseDecimal128 encodes s exactly, failing rather than rounding when s has
+// more significant digits or a wider exponent than decimal128 holds.
+func parseDecimal128(s string) (high, low uint64, err error) {
+	var str = s
+	var neg bool
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		neg, str = str[0] == '-', str[1:]
+	}
+	var sign uint64
+	if neg {
+		sign = 1 << 63
+	}
+	switch strings.ToLower(str) {
+	case "inf", "infinity":
+		return sign | 0x78<<56, 0, nil
+	case "nan":
+		return 0x7c << 56, 0, nil
+	}
+
+	var exp int
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		if exp, err = strconv.Atoi(str[i+1:]); err != nil {
+			return 0, 0, fmt.Errorf("jsons: invalid decimal %q", s)
+		}
+		str = str[:i]
+	}
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		exp -= len(str) - i - 1
+		str = str[:i] + str[i+1:]
+	}
+	if str == "" || strings.Trim(str, "0123456789") != "" {
+		return 0, 0, fmt.Errorf("jsons: invalid decimal %q", s)
+	}
+	// Zeros can only be added or dropped to fix the exponent as far as the
+	// digits allow, so reject hopeless exponents before looping.
+	var tooLarge, tooSmall = exp > decimalMaxExponent+decimalMaxDigits, exp < decimalMinExponent-len(str)
+	if (tooLarge || tooSmall) && strings.Trim(str, "0") != "" {
+		return 0, 0, fmt.Errorf("jsons: decimal %q does not fit in decimal128", s)
+	}
+	coeff, _ := new(big.Int).SetString(str, 10)
+
+	// Drop trailing zeros while the coefficient is too large or the exponent
+	// too small, and add them while the exponent is too large.
+	var ten = big.NewInt(10)
+	for coeff.Cmp(decimalMaxCoefficient) > 0 || exp < decimalMinExponent {
+		if coeff.Sign() == 0 {
+			exp = decimalMinExponent
+			break
+		}
+		q, m := new(big.Int).QuoRem(coeff, ten, new(big.Int))
+		if m.Sign() != 0 {
+			return 0, 0, fmt.Errorf("jsons: decimal %q does not fit in decimal128", s)
+		}
+		coeff, exp = q, exp+1
+	}
+	for exp > decimalMaxExponent {
+		if coeff.Sign() == 0 {
+			exp = decimalMaxExponent
+			break
+		}
+		coeff.Mul(coeff, ten)
+		if coeff.Cmp(decimalMaxCoefficient) > 0 {
+			return 0, 0, fmt.Errorf("jsons: decimal %q does not fit in decimal128", s)
+		}
+		exp--
+	}
+
+	var b = coeff.FillBytes(make([]byte, 16))
+	for _, c := range b[:8] {
+		high = high<<8 | uint64(c)
+	}
+	for _, c := range b[8:] {
+		low = low<<8 | uint64(c)
+	}
+	return sign | uint64(exp+decimalBias)<<49 | high, low, nil
+}
+
+// formatDecimal128 formats a decimal128 like the IEEE to-scientific-string
+// operation, as the BSON specification requires.
+func formatDecimal128(high, low uint64) string {
+	var sign string
+	if high>>63 != 0 {
+		sign = "-"
+	}
+	switch {
+	case high>>58&0x1f == 0x1f:
+		return "NaN"
+	case high>>58&0x1f == 0x1e:
+		return sign + "Infinity"
+	}
+
+	var exp int
+	var coeff = new(big.Int)
+	if high>>61&0x3 == 0x3 {
+		// The coefficient would exceed 10^34 - 1, so it is non-canonical
+		// and read as zero.
+		exp = int(high>>47&0x3fff) - decimalBias
+	} else {
+		exp = int(high>>49&0x3fff) - decimalBias
+		coeff.SetUint64(high & (1<<49 - 1))
+		coeff.Lsh(coeff, 64).Or(coeff, new(big.Int).SetUint64(low))
+		if coeff.Cmp(decimalMaxCoefficient) > 0 {
+			coeff.SetInt64(0)
+		}
+	}
+
+	var digits = coeff.String()
+	var adjusted = exp + len(digits) - 1
+	switch {
+	case exp == 0:
+		return sign + digits
+	case exp < 0 && adjusted >= -6:
+		if n := len(digits) + exp; n > 0 {
+			return sign + digits[:n] + "." + digits[n:]
+		}
+		return sign + "0." + strings.Repeat("0", -exp-len(digits)) + digits
+	}
+	var s = sign + digits[:1]
+	if len(digits) > 1 {
+		s += "." + digits[1:]
+	}
+	if adjusted >= 0 {
+		return s + "E+" + strconv.Itoa(adjusted)
+	}
+	return s + "E" + strconv.Itoa(adjusted)
+}
@@ -0,0 +1,738 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	valueType           = reflect.TypeOf(Value{})
+	numberType          = reflect.TypeOf(Number(""))
+	arrayType           = reflect.TypeOf(Array(nil))
+	objectType          = reflect.TypeOf(Object(nil))
+	jsonNumberType      = reflect.TypeOf(json.Number(""))
+)
+
+// DecodeOptions controls how Value.DecodeWithOptions maps a Value tree onto
+// Go values. The zero value behaves like encoding/json.
+type DecodeOptions struct {
+	// DisallowUnknownFields reports object keys that match no struct field.
+	DisallowUnknownFields bool
+	// CaseSensitive matches object keys to struct fields exactly instead of
+	// falling back to a case-insensitive match.
+	CaseSensitive bool
+	// WeaklyTyped converts between strings, numbers and bools as needed by
+	// the target, and wraps single values into one-element slices.
+	WeaklyTyped bool
+	// UseNumber decodes numbers into interface{} as json.Number instead of
+	// float64.
+	UseNumber bool
+}
+
+// DecodeError reports the JSON path of the value that failed to decode.
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("jsons: decode %s: %v", e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decoder reflects values of a Value tree directly into Go values.
+type decoder struct {
+	opts DecodeOptions
+	path []interface{}
+}
+
+// normalize reduces a node of a Value tree to one of nil, Bool, Number,
+// String, Array or Object. Values the tree cannot represent natively are
+// converted through encoding/json.
+func normalize(src interface{}) (interface{}, error) {
+	switch v := src.(type) {
+	case nil, Bool, Number, String, Array, Object:
+		return v, nil
+	case Value:
+		return normalize(v.value)
+	case bool:
+		return Bool(v), nil
+	case json.Number:
+		return Number(v), nil
+	case string:
+		return String(v), nil
+	case []interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		return Array(v), nil
+	case map[string]interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		return Object(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value(v).value, nil
+	case Raw:
+		return normalizeJSON(v)
+	case json.RawMessage:
+		return normalizeJSON(v)
+	}
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeJSON(data)
+}
+
+func normalizeJSON(data []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	val, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(val.value)
+}
+
+// normalizeTree is normalize applied throughout a tree: it returns a copy
+// built of nil, Bool, Number, String, Array and Object only.
+func normalizeTree(src interface{}) (interface{}, error) {
+	src, err := normalize(src)
+	if err != nil {
+		return nil, err
+	}
+	switch v := src.(type) {
+	case Array:
+		var arr = make(Array, len(v))
+		for i, elem := range v {
+			if arr[i], err = normalizeTree(elem); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case Object:
+		var obj = make(Object, len(v))
+		for key, elem := range v {
+			if obj[key], err = normalizeTree(elem); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	}
+	return src, nil
+}
+
+// deepCopy returns a copy of a Value tree that shares no containers with
+// src. Leaves keep their representation unless the tree cannot hold them.
+func deepCopy(src interface{}) (interface{}, error) {
+	var err error
+	switch v := src.(type) {
+	case nil, bool, string, json.Number, Bool, Number, String:
+		return v, nil
+	case Value:
+		return deepCopy(v.value)
+	case Array:
+		var arr = make(Array, len(v))
+		for i, elem := range v {
+			if arr[i], err = deepCopy(elem); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case []interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		arr, err := deepCopy(Array(v))
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}(arr.(Array)), nil
+	case Object:
+		var obj = make(Object, len(v))
+		for key, elem := range v {
+			if obj[key], err = deepCopy(elem); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	case map[string]interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		obj, err := deepCopy(Object(v))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}(obj.(Object)), nil
+	}
+	if src, err = normalize(src); err != nil {
+		return nil, err
+	}
+	return deepCopy(src)
+}
+
+// sortedKeys makes decoding, and so the error reported, deterministic.
+func sortedKeys(obj Object) []string {
+	var keys = make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeName(src interface{}) string {
+	switch src.(type) {
+	case nil:
+		return "null"
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Object:
+		return "object"
+	}
+	return fmt.Sprintf("%T", src)
+}
+
+func (d *decoder) decode(src interface{}, dst reflect.Value) error {
+	err := d.decodeValue(src, dst)
+	if _, ok := err.(*DecodeError); err != nil && !ok {
+		return &DecodeError{Path: JSONPath(d.path...), Err: err}
+	}
+	return err
+}
+
+func (d *decoder) decodeAt(v Value, keys []interface{}, dst reflect.Value) error {
+	d.path = append(d.path[:0], keys...)
+	return d.decode(v.Get(keys...).value, dst)
+}
+
+func (d *decoder) enter(key interface{}) {
+	d.path = append(d.path, key)
+}
+
+func (d *decoder) leave() {
+	d.path = d.path[:len(d.path)-1]
+}
+
+func (d *decoder) decodeValue(src interface{}, dst reflect.Value) error {
+	src, err := normalize(src)
+	if err != nil {
+		return err
+	}
+
+	// null leaves non-nillable values untouched, like encoding/json.
+	if src == nil {
+		switch dst.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		if dst.Type() == valueType {
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return d.decodeValue(src, dst.Elem())
+	}
+
+	switch dst.Type() {
+	case valueType:
+		clone, err := deepCopy(src)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(value(clone)))
+		return nil
+	case arrayType, objectType:
+		if reflect.TypeOf(src) != dst.Type() {
+			return d.mismatch(src, dst.Type())
+		}
+		clone, err := deepCopy(src)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(clone))
+		return nil
+	case numberType, jsonNumberType:
+		if num, ok := src.(Number); ok {
+			dst.SetString(string(num))
+			return nil
+		}
+		return d.mismatch(src, dst.Type())
+	}
+
+	if dst.CanAddr() {
+		switch ptr := dst.Addr(); {
+		case ptr.Type().Implements(jsonUnmarshalerType):
+			data, err := json.Marshal(src)
+			if err != nil {
+				return err
+			}
+			return ptr.Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		case ptr.Type().Implements(textUnmarshalerType):
+			if str, ok := src.(String); ok {
+				return ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+			}
+			return d.mismatch(src, dst.Type())
+		}
+	}
+
+	if d.opts.WeaklyTyped {
+		src = weaken(src, dst.Type())
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() > 0 {
+			return d.mismatch(src, dst.Type())
+		}
+		val, err := d.plain(src)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(val))
+		return nil
+	case reflect.Bool:
+		if b, ok := src.(Bool); ok {
+			dst.SetBool(bool(b))
+			return nil
+		}
+	case reflect.String:
+		if str, ok := src.(String); ok {
+			dst.SetString(string(str))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if num, ok := src.(Number); ok {
+			i, err := strconv.ParseInt(string(num), 10, 64)
+			if err != nil || dst.OverflowInt(i) {
+				return fmt.Errorf("cannot decode number %s into %s", num, dst.Type())
+			}
+			dst.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if num, ok := src.(Number); ok {
+			u, err := strconv.ParseUint(string(num), 10, 64)
+			if err != nil || dst.OverflowUint(u) {
+				return fmt.Errorf("cannot decode number %s into %s", num, dst.Type())
+			}
+			dst.SetUint(u)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if num, ok := src.(Number); ok {
+			f, err := strconv.ParseFloat(string(num), dst.Type().Bits())
+			if err != nil || dst.OverflowFloat(f) {
+				return fmt.Errorf("cannot decode number %s into %s", num, dst.Type())
+			}
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			if str, ok := src.(String); ok {
+				data, err := base64.StdEncoding.DecodeString(string(str))
+				if err != nil {
+					return err
+				}
+				dst.SetBytes(data)
+				return nil
+			}
+		}
+		if arr, ok := src.(Array); ok {
+			slice := reflect.MakeSlice(dst.Type(), len(arr), len(arr))
+			for i, elem := range arr {
+				d.enter(i)
+				if err := d.decode(elem, slice.Index(i)); err != nil {
+					return err
+				}
+				d.leave()
+			}
+			dst.Set(slice)
+			return nil
+		}
+	case reflect.Array:
+		if arr, ok := src.(Array); ok {
+			for i := 0; i < dst.Len(); i++ {
+				if i >= len(arr) {
+					dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+					continue
+				}
+				d.enter(i)
+				if err := d.decode(arr[i], dst.Index(i)); err != nil {
+					return err
+				}
+				d.leave()
+			}
+			return nil
+		}
+	case reflect.Map:
+		if obj, ok := src.(Object); ok {
+			return d.decodeMap(obj, dst)
+		}
+	case reflect.Struct:
+		if obj, ok := src.(Object); ok {
+			return d.decodeStruct(obj, dst)
+		}
+	}
+
+	return d.mismatch(src, dst.Type())
+}
+
+// weaken converts src towards the kind of typ for WeaklyTyped decoding.
+func weaken(src interface{}, typ reflect.Type) interface{} {
+	switch typ.Kind() {
+	case reflect.Bool:
+		switch v := src.(type) {
+		case String:
+			if b, err := strconv.ParseBool(strings.TrimSpace(string(v))); err == nil {
+				return Bool(b)
+			}
+		case Number:
+			if f, err := v.Float64(); err == nil {
+				return Bool(f != 0)
+			}
+		}
+	case reflect.String:
+		switch v := src.(type) {
+		case Number:
+			return String(v)
+		case Bool:
+			return String(strconv.FormatBool(bool(v)))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch v := src.(type) {
+		case String:
+			return weaken(Number(strings.TrimSpace(string(v))), typ)
+		case Bool:
+			if v {
+				return Number("1")
+			}
+			return Number("0")
+		case Number:
+			if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+				return v
+			}
+			if _, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+				return v
+			}
+			if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+				return Number(strconv.FormatFloat(f, 'f', -1, 64))
+			}
+			return v
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v := src.(type) {
+		case String:
+			return Number(strings.TrimSpace(string(v)))
+		case Bool:
+			if v {
+				return Number("1")
+			}
+			return Number("0")
+		}
+	case reflect.Slice, reflect.Array:
+		if _, ok := src.(String); ok && typ.Elem().Kind() == reflect.Uint8 {
+			return src
+		}
+		if _, ok := src.(Array); !ok {
+			return Array{src}
+		}
+	}
+	return src
+}
+
+func (d *decoder) mismatch(src interface{}, typ reflect.Type) error {
+	return fmt.Errorf("cannot decode %s into %s", typeName(src), typ)
+}
+
+// plain converts a normalized node into the representation encoding/json
+// uses for interface{} values.
+func (d *decoder) plain(src interface{}) (interface{}, error) {
+	src, err := normalize(src)
+	if err != nil {
+		return nil, err
+	}
+	switch v := src.(type) {
+	case Bool:
+		return bool(v), nil
+	case Number:
+		if d.opts.UseNumber {
+			return json.Number(v), nil
+		}
+		return strconv.ParseFloat(string(v), 64)
+	case String:
+		return string(v), nil
+	case Array:
+		var arr = make([]interface{}, len(v))
+		for i, elem := range v {
+			if arr[i], err = d.plain(elem); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case Object:
+		var obj = make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			if obj[key], err = d.plain(elem); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	}
+	return nil, nil
+}
+
+func (d *decoder) decodeMap(obj Object, dst reflect.Value) error {
+	var typ = dst.Type()
+	switch typ.Key().Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !reflect.PtrTo(typ.Key()).Implements(textUnmarshalerType) {
+			return fmt.Errorf("cannot decode object into %s", typ)
+		}
+	}
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(typ, len(obj)))
+	}
+	for _, key := range sortedKeys(obj) {
+		val := obj[key]
+		d.enter(key)
+		k, err := d.mapKey(key, typ.Key())
+		if err != nil {
+			return &DecodeError{Path: JSONPath(d.path...), Err: err}
+		}
+		elem := reflect.New(typ.Elem()).Elem()
+		if err = d.decode(val, elem); err != nil {
+			return err
+		}
+		dst.SetMapIndex(k, elem)
+		d.leave()
+	}
+	return nil
+}
+
+func (d *decoder) mapKey(key string, typ reflect.Type) (reflect.Value, error) {
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		k := reflect.New(typ)
+		if err := k.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, err
+		}
+		return k.Elem(), nil
+	}
+	var k = reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(i) {
+			return k, fmt.Errorf("cannot decode key %q into %s", key, typ)
+		}
+		k.SetInt(i)
+	default:
+		u, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(u) {
+			return k, fmt.Errorf("cannot decode key %q into %s", key, typ)
+		}
+		k.SetUint(u)
+	}
+	return k, nil
+}
+
+func (d *decoder) decodeStruct(obj Object, dst reflect.Value) error {
+	var fields = cachedFields(dst.Type())
+	for _, key := range sortedKeys(obj) {
+		val := obj[key]
+		d.enter(key)
+		f := fields.lookup(key, d.opts.CaseSensitive)
+		if f == nil {
+			if d.opts.DisallowUnknownFields {
+				return &DecodeError{Path: JSONPath(d.path...), Err: fmt.Errorf("unknown field %q in %s", key, dst.Type())}
+			}
+			d.leave()
+			continue
+		}
+		field, err := fieldByIndex(dst, f.index)
+		if err == nil && f.quoted {
+			val, err = unquote(val)
+		}
+		if err == nil {
+			err = d.decode(val, field)
+		}
+		if err != nil {
+			if _, ok := err.(*DecodeError); !ok {
+				err = &DecodeError{Path: JSONPath(d.path...), Err: err}
+			}
+			return err
+		}
+		d.leave()
+	}
+	return nil
+}
+
+// unquote unwraps a value stored with the ",string" tag option.
+func unquote(src interface{}) (interface{}, error) {
+	src, err := normalize(src)
+	if err != nil {
+		return nil, err
+	}
+	str, ok := src.(String)
+	if !ok {
+		return src, nil
+	}
+	return normalizeJSON([]byte(str))
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return v, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v, nil
+}
+
+type field struct {
+	name   string
+	index  []int
+	quoted bool
+}
+
+type fields struct {
+	list   []field
+	byName map[string]*field
+	byFold map[string]*field
+}
+
+func (f *fields) lookup(key string, caseSensitive bool) *field {
+	if field, ok := f.byName[key]; ok || caseSensitive {
+		return field
+	}
+	return f.byFold[strings.ToLower(key)]
+}
+
+var fieldCache sync.Map // map[reflect.Type]*fields
+
+func cachedFields(typ reflect.Type) *fields {
+	if f, ok := fieldCache.Load(typ); ok {
+		return f.(*fields)
+	}
+	f, _ := fieldCache.LoadOrStore(typ, typeFields(typ))
+	return f.(*fields)
+}
+
+// typeFields returns the fields encoding/json would use for typ, promoting
+// the fields of embedded structs. Shallower fields hide deeper ones.
+func typeFields(typ reflect.Type) *fields {
+	var result = &fields{
+		byName: make(map[string]*field),
+		byFold: make(map[string]*field),
+	}
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var current []embedded
+	var next = []embedded{{typ: typ}}
+	var visited = make(map[reflect.Type]bool)
+	var seen = make(map[string]bool)
+	for len(next) > 0 {
+		current, next = next, nil
+		var names = make(map[string]int)
+		var level []field
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if idx := strings.Index(tag, ","); idx >= 0 {
+					name, opts = tag[:idx], tag[idx+1:]
+				}
+				index := append(append([]int(nil), e.index...), i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+				if sf.PkgPath != "" {
+					continue
+				}
+				if name == "" {
+					name = sf.Name
+				}
+				quoted := false
+				for _, opt := range strings.Split(opts, ",") {
+					if opt == "string" {
+						quoted = true
+					}
+				}
+				names[name]++
+				level = append(level, field{name: name, index: index, quoted: quoted})
+			}
+		}
+		for _, f := range level {
+			if names[f.name] > 1 || seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			result.list = append(result.list, f)
+		}
+	}
+	for i := range result.list {
+		f := &result.list[i]
+		result.byName[f.name] = f
+		if _, exists := result.byFold[strings.ToLower(f.name)]; !exists {
+			result.byFold[strings.ToLower(f.name)] = f
+		}
+	}
+	return result
+}
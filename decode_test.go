@@ -0,0 +1,69 @@
+package jsons
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_Decode(t *testing.T) {
+	val, err := Unmarshal([]byte(`{
+		"data": {
+			"user": {
+				"Name": "seven",
+				"age": "18",
+				"admin": 1,
+				"tags": "a",
+				"extra": 1
+			},
+			"list": [{"id": 1}, {"id": "x"}]
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type User struct {
+		Name  string   `json:"name"`
+		Age   int      `json:"age"`
+		Admin bool     `json:"admin"`
+		Tags  []string `json:"tags"`
+	}
+
+	var user User
+	err = val.Decode(&user, "data", "user")
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, decodeErr.Path, "$.data.user.admin")
+	assert.EqualError(t, err, "jsons: decode $.data.user.admin: cannot decode number into bool")
+
+	user = User{}
+	err = val.DecodeWithOptions(&user, DecodeOptions{WeaklyTyped: true}, "data", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, user, User{Name: "seven", Age: 18, Admin: true, Tags: []string{"a"}})
+
+	user = User{}
+	err = val.DecodeWithOptions(&user, DecodeOptions{WeaklyTyped: true, CaseSensitive: true}, "data", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, user.Name, "")
+
+	err = val.DecodeWithOptions(&user, DecodeOptions{WeaklyTyped: true, DisallowUnknownFields: true}, "data", "user")
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, decodeErr.Path, "$.data.user.extra")
+
+	var list []struct {
+		ID int `json:"id"`
+	}
+	err = val.Decode(&list, "data", "list")
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, decodeErr.Path, "$.data.list[1].id")
+
+	var any interface{}
+	assert.NoError(t, val.DecodeWithOptions(&any, DecodeOptions{UseNumber: true}, "data", "user", "admin"))
+	assert.Equal(t, any, json.Number("1"))
+
+	assert.Error(t, val.Decode(user))
+	assert.Error(t, val.Decode(nil))
+}
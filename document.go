@@ -0,0 +1,576 @@
+package jsons
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrNotFound = errors.New("path not found")
+
+// Document is a JSONC document that can be edited by path while its
+// comments, whitespace and key order stay untouched. Edits splice the
+// original bytes; only the edited member is rewritten.
+type Document struct {
+	data []byte
+	root *cstNode
+}
+
+// cstNode is a node of the concrete syntax tree: the byte span of a value
+// and, for containers, the spans of their members.
+type cstNode struct {
+	start, end int // value span, end exclusive
+	object     bool
+	array      bool
+	members    []cstMember
+}
+
+type cstMember struct {
+	key      string // object members only
+	keyStart int    // start of the key, or of the value in arrays
+	value    *cstNode
+	comma    int // offset of the following comma, -1 if none
+}
+
+func ParseDocument(data []byte) (*Document, error) {
+	var d = &Document{data: append([]byte(nil), data...)}
+	if err := d.parse(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Document) parse() error {
+	var p = cstParser{relaxedParser{data: d.data}}
+	if err := p.space(); err != nil {
+		return err
+	}
+	root, err := p.node()
+	if err != nil {
+		return err
+	}
+	if err = p.space(); err != nil {
+		return err
+	}
+	if p.pos < len(p.data) {
+		return p.error(ErrTrailingData)
+	}
+	d.root = root
+	return nil
+}
+
+type cstParser struct {
+	relaxedParser
+}
+
+func (p *cstParser) node() (*cstNode, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected()
+	}
+	var n = &cstNode{start: p.pos}
+	var closing byte
+	switch p.data[p.pos] {
+	case '{':
+		n.object, closing = true, '}'
+	case '[':
+		n.array, closing = true, ']'
+	default:
+		if _, err := p.value(); err != nil {
+			return nil, err
+		}
+		n.end = p.pos
+		return n, nil
+	}
+
+	p.pos++
+	for {
+		if err := p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == closing {
+			p.pos++
+			n.end = p.pos
+			return n, nil
+		}
+		var m = cstMember{keyStart: p.pos, comma: -1}
+		if n.object {
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.space(); err != nil {
+				return nil, err
+			}
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return nil, p.unexpected()
+			}
+			p.pos++
+			if err = p.space(); err != nil {
+				return nil, err
+			}
+			m.key = key
+			p.path = append(p.path, key)
+		} else {
+			p.path = append(p.path, len(n.members))
+		}
+		value, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		p.path = p.path[:len(p.path)-1]
+		m.value = value
+		if err = p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			m.comma = p.pos
+			p.pos++
+			n.members = append(n.members, m)
+			continue
+		}
+		n.members = append(n.members, m)
+		if p.pos < len(p.data) && p.data[p.pos] == closing {
+			p.pos++
+			n.end = p.pos
+			return n, nil
+		}
+		return nil, p.unexpected()
+	}
+}
+
+// find returns the index of the member addressed by key, or -1.
+func (n *cstNode) find(key interface{}) int {
+	switch k := key.(type) {
+	case string:
+		if n.object {
+			// the last duplicate wins, like Unmarshal
+			for i := len(n.members) - 1; i >= 0; i-- {
+				if n.members[i].key == k {
+					return i
+				}
+			}
+		}
+	case int:
+		if n.array && k >= 0 && k < len(n.members) {
+			return k
+		}
+	}
+	return -1
+}
+
+func (d *Document) lookup(keys []interface{}) (*cstNode, error) {
+	var n = d.root
+	for i, key := range keys {
+		idx := n.find(key)
+		if idx < 0 {
+			return nil, fmt.Errorf("jsons: %s: %w", JSONPath(keys[:i+1]...), ErrNotFound)
+		}
+		n = n.members[idx].value
+	}
+	return n, nil
+}
+
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+func (d *Document) String() string {
+	return string(d.data)
+}
+
+// Value returns the value at keys with comments stripped.
+func (d *Document) Value(keys ...interface{}) (Value, error) {
+	n, err := d.lookup(keys)
+	if err != nil {
+		return Value{}, err
+	}
+	return UnmarshalJSONC(d.data[n.start:n.end])
+}
+
+func (d *Document) Exist(keys ...interface{}) bool {
+	_, err := d.lookup(keys)
+	return err == nil
+}
+
+// Set replaces the value at keys, the last argument being the new value.
+// Missing object members are created, including missing parent objects;
+// an array index equal to the array length appends.
+func (d *Document) Set(keys ...interface{}) error {
+	if len(keys) == 0 {
+		return errors.New("jsons: Set needs a value")
+	}
+	val, path := keys[len(keys)-1], keys[:len(keys)-1]
+
+	var n = d.root
+	for i, key := range path {
+		idx := n.find(key)
+		if idx >= 0 {
+			n = n.members[idx].value
+			continue
+		}
+		if index, ok := key.(int); ok && n.array && index == len(n.members) && i == len(path)-1 {
+			return d.insert(n, len(n.members), "", val)
+		}
+		if _, ok := key.(string); !ok || !n.object {
+			return fmt.Errorf("jsons: %s: %w", JSONPath(path[:i+1]...), ErrNotFound)
+		}
+		// wrap the value in the objects that are missing
+		for j := len(path) - 1; j > i; j-- {
+			name, ok := path[j].(string)
+			if !ok {
+				return fmt.Errorf("jsons: %s: %w", JSONPath(path[:j+1]...), ErrNotFound)
+			}
+			val = Object{name: val}
+		}
+		return d.insert(n, len(n.members), key.(string), val)
+	}
+	data, err := d.encode(val, n.start)
+	if err != nil {
+		return err
+	}
+	return d.splice(edit{n.start, n.end, data})
+}
+
+// Insert inserts a value into an array before the index given as the last
+// key, the last argument being the value.
+func (d *Document) Insert(keys ...interface{}) error {
+	if len(keys) < 2 {
+		return errors.New("jsons: Insert needs an index and a value")
+	}
+	val, path := keys[len(keys)-1], keys[:len(keys)-2]
+	index, ok := keys[len(keys)-2].(int)
+	if !ok {
+		return fmt.Errorf("jsons: Insert needs an array index, got %v", keys[len(keys)-2])
+	}
+	n, err := d.lookup(path)
+	if err != nil {
+		return err
+	}
+	if !n.array {
+		return fmt.Errorf("jsons: %s is not an array", JSONPath(path...))
+	}
+	if index < 0 || index > len(n.members) {
+		return fmt.Errorf("jsons: %s: %w", JSONPath(keys[:len(keys)-1]...), ErrNotFound)
+	}
+	return d.insert(n, index, "", val)
+}
+
+// Delete removes the member at keys together with its comma and any
+// comment on the rest of its line. A duplicated object key has all of its
+// members removed, so that no earlier one takes the deleted one's place.
+func (d *Document) Delete(keys ...interface{}) error {
+	if len(keys) == 0 {
+		return errors.New("jsons: cannot delete the root")
+	}
+	if err := d.delete(keys); err != nil {
+		return err
+	}
+	if _, ok := keys[len(keys)-1].(string); !ok {
+		return nil
+	}
+	var data, root = d.data, d.root
+	for d.Exist(keys...) {
+		if err := d.delete(keys); err != nil {
+			d.data, d.root = data, root
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Document) delete(keys []interface{}) error {
+	parent, err := d.lookup(keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	idx := parent.find(keys[len(keys)-1])
+	if idx < 0 {
+		return fmt.Errorf("jsons: %s: %w", JSONPath(keys...), ErrNotFound)
+	}
+	var m = parent.members[idx]
+	var start, end = m.keyStart, m.value.end
+	if m.comma >= 0 {
+		end = m.comma + 1
+	}
+
+	var edits []edit
+	var ls = d.lineStart(start)
+	var rest = d.skipLineComment(end)
+	switch {
+	case d.skipBlank(ls) == start && (rest == len(d.data) || d.data[rest] == '\n'):
+		// the member is alone on its lines: remove them with its comment
+		start, end = ls, rest
+		if end < len(d.data) {
+			end++
+		}
+		if m.comma < 0 && idx > 0 {
+			if prev := parent.members[idx-1]; prev.comma >= 0 {
+				edits = append(edits, edit{prev.comma, prev.comma + 1, nil})
+			}
+		}
+	case m.comma < 0 && idx > 0:
+		// inline last member: remove from the end of its predecessor
+		start = parent.members[idx-1].value.end
+	default:
+		end = d.skipBlank(end)
+	}
+	edits = append(edits, edit{start, end, nil})
+	return d.splice(edits...)
+}
+
+// insert adds a member to the object or array n before member idx.
+func (d *Document) insert(n *cstNode, idx int, key string, val interface{}) error {
+	var indent, multiline = d.memberIndent(n)
+	var colon, gap = d.separators(n, multiline)
+	var nl = d.newline()
+
+	var at = n.end - 1
+	if idx < len(n.members) {
+		at = n.members[idx].keyStart
+	}
+	var prefix = indent
+	if !multiline {
+		prefix = ""
+	}
+	data, err := d.encodeAt(val, prefix)
+	if err != nil {
+		return err
+	}
+	var entry []byte
+	if n.object {
+		entry = append(quote(key), colon...)
+	}
+	entry = append(entry, data...)
+
+	// before an existing member
+	if idx < len(n.members) {
+		var text []byte
+		text = append(text, entry...)
+		text = append(text, ',')
+		if ls := d.lineStart(at); multiline && d.skipBlank(ls) == at {
+			text = append(append(text, nl...), d.data[ls:at]...)
+		} else {
+			text = append(text, gap...)
+		}
+		return d.splice(edit{at, at, text})
+	}
+
+	// into an empty container
+	if len(n.members) == 0 {
+		if !multiline {
+			return d.splice(edit{at, at, entry})
+		}
+		closing := indent[:len(indent)-len(d.indentUnit())]
+		text := append(append([]byte(nl), indent...), entry...)
+		text = append(append(text, nl...), closing...)
+		// drop whitespace already between the brackets
+		return d.splice(edit{n.start + 1, d.skipSpace(n.start + 1), nil}, edit{at, at, text})
+	}
+
+	// after the last member
+	var last = n.members[len(n.members)-1]
+	var edits []edit
+	var after = last.value.end
+	if last.comma >= 0 {
+		after = last.comma + 1
+		entry = append(entry, ',')
+	} else {
+		edits = append(edits, edit{after, after, []byte{','}})
+	}
+	if eol := d.skipLineComment(after); multiline && eol < len(d.data) && d.data[eol] == '\n' && eol < n.end {
+		if d.data[eol-1] == '\r' {
+			eol--
+		}
+		text := append(append([]byte(nl), indent...), entry...)
+		edits = append(edits, edit{eol, eol, text})
+	} else {
+		edits = append(edits, edit{after, after, append([]byte(gap), entry...)})
+	}
+	return d.splice(edits...)
+}
+
+// separators returns the text after colons and commas of new members of n,
+// following the members of n or, if they do not show it, of the first
+// container in the document that does.
+func (d *Document) separators(n *cstNode, multiline bool) (colon, gap string) {
+	var colonKnown, gapKnown bool
+	var look = func(n *cstNode) {
+		for _, m := range n.members {
+			if n.object && !colonKnown {
+				colon, colonKnown = ":", true
+				if c := d.data[m.value.start-1]; c == ' ' || c == '\t' {
+					colon = ": "
+				}
+			}
+			if m.comma >= 0 && m.comma+1 < len(d.data) && !gapKnown {
+				switch d.data[m.comma+1] {
+				case ' ':
+					gap, gapKnown = " ", true
+				case '\t', '\r', '\n', '/':
+				default:
+					gapKnown = true
+				}
+			}
+		}
+	}
+	look(n)
+	for queue := []*cstNode{d.root}; len(queue) > 0 && !(colonKnown && gapKnown); queue = queue[1:] {
+		look(queue[0])
+		for _, m := range queue[0].members {
+			queue = append(queue, m.value)
+		}
+	}
+	if !colonKnown && multiline {
+		colon = ": "
+	} else if !colonKnown {
+		colon = ":"
+	}
+	if !gapKnown && multiline {
+		gap = " "
+	}
+	return colon, gap
+}
+
+func quote(s string) []byte {
+	var e encodeState
+	e.string(s)
+	return e.Bytes()
+}
+
+type edit struct {
+	start, end int
+	data       []byte
+}
+
+// splice applies non-overlapping edits to the document and re-parses it.
+// Insertions at the same offset keep their order.
+func (d *Document) splice(edits ...edit) error {
+	for i := len(edits)/2 - 1; i >= 0; i-- {
+		edits[i], edits[len(edits)-1-i] = edits[len(edits)-1-i], edits[i]
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	var data = append([]byte(nil), d.data...)
+	for _, e := range edits {
+		data = append(data[:e.start], append(append([]byte(nil), e.data...), data[e.end:]...)...)
+	}
+	var old = d.data
+	d.data = data
+	if err := d.parse(); err != nil {
+		d.data = old
+		_ = d.parse()
+		return err
+	}
+	return nil
+}
+
+// encode formats val for the position at, indenting nested lines like the
+// line it starts on.
+func (d *Document) encode(val interface{}, at int) ([]byte, error) {
+	if !d.multiline() {
+		return d.encodeAt(val, "")
+	}
+	ls := d.lineStart(at)
+	return d.encodeAt(val, string(d.data[ls:d.skipBlank(ls)]))
+}
+
+func (d *Document) encodeAt(val interface{}, prefix string) ([]byte, error) {
+	var opts EncodeOptions
+	if d.multiline() {
+		opts.Prefix, opts.Indent = prefix, d.indentUnit()
+	}
+	data, err := MarshalWithOptions(val, opts)
+	if err != nil {
+		return nil, err
+	}
+	if nl := d.newline(); nl != "\n" {
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte(nl))
+	}
+	return data, nil
+}
+
+// multiline reports whether the document puts members on their own lines.
+func (d *Document) multiline() bool {
+	if d.root == nil || !d.root.object && !d.root.array {
+		return true
+	}
+	return bytes.IndexByte(d.data[d.root.start:d.root.end], '\n') >= 0
+}
+
+// indentUnit guesses the document's indentation from its first indented line.
+func (d *Document) indentUnit() string {
+	for _, line := range bytes.Split(d.data, []byte{'\n'}) {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return string(line[:len(line)-len(trimmed)])
+		}
+	}
+	return "  "
+}
+
+// newline returns the document's line ending, taken from its first line.
+func (d *Document) newline() string {
+	if i := bytes.IndexByte(d.data, '\n'); i > 0 && d.data[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// memberIndent returns the indentation for a new member of n.
+func (d *Document) memberIndent(n *cstNode) (string, bool) {
+	if !d.multiline() {
+		return "", false
+	}
+	for i := len(n.members) - 1; i >= 0; i-- {
+		start := n.members[i].keyStart
+		if ls := d.lineStart(start); d.skipBlank(ls) == start {
+			return string(d.data[ls:start]), true
+		}
+	}
+	ls := d.lineStart(n.start)
+	return string(d.data[ls:d.skipBlank(ls)]) + d.indentUnit(), true
+}
+
+func (d *Document) lineStart(pos int) int {
+	return bytes.LastIndexByte(d.data[:pos], '\n') + 1
+}
+
+func (d *Document) skipBlank(pos int) int {
+	for pos < len(d.data) && (d.data[pos] == ' ' || d.data[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+func (d *Document) skipSpace(pos int) int {
+	for pos < len(d.data) && bytes.IndexByte([]byte(" \t\r\n"), d.data[pos]) >= 0 {
+		pos++
+	}
+	return pos
+}
+
+// skipLineComment skips blanks and comments up to the end of the line.
+func (d *Document) skipLineComment(pos int) int {
+	for {
+		pos = d.skipBlank(pos)
+		switch {
+		case bytes.HasPrefix(d.data[pos:], []byte("//")):
+			for pos < len(d.data) && d.data[pos] != '\n' {
+				pos++
+			}
+			return pos
+		case bytes.HasPrefix(d.data[pos:], []byte("/*")):
+			end := bytes.Index(d.data[pos+2:], []byte("*/"))
+			if end < 0 || bytes.IndexByte(d.data[pos:pos+2+end], '\n') >= 0 {
+				return pos
+			}
+			pos += end + 4
+		case pos < len(d.data) && d.data[pos] == '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+}
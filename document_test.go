@@ -0,0 +1,127 @@
+package jsons
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDocument(t *testing.T) {
+	doc, err := ParseDocument([]byte(`// service config
+{
+    // where to listen
+    "listen": "127.0.0.1:8080", // loopback only
+    "db": {
+        "host": "localhost",
+        "port": 5432 /* default */
+    },
+    "tags": ["a", "b"],
+    "empty": {},
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := doc.Value("db", "port")
+	assert.NoError(t, err)
+	assert.Equal(t, val.Int(), int64(5432))
+	assert.True(t, doc.Exist("tags", 1))
+	assert.False(t, doc.Exist("tags", 2))
+
+	assert.NoError(t, doc.Set("listen", "0.0.0.0:80"))
+	assert.NoError(t, doc.Set("db", "user", "admin"))
+	assert.NoError(t, doc.Set("db", "pool", "size", 10))
+	assert.NoError(t, doc.Set("tags", 2, "c"))
+	assert.NoError(t, doc.Insert("tags", 0, "z"))
+	assert.NoError(t, doc.Set("empty", "k", Object{"v": true}))
+	assert.NoError(t, doc.Set("debug", false))
+	assert.Equal(t, doc.String(), `// service config
+{
+    // where to listen
+    "listen": "0.0.0.0:80", // loopback only
+    "db": {
+        "host": "localhost",
+        "port": 5432, /* default */
+        "user": "admin",
+        "pool": {
+            "size": 10
+        }
+    },
+    "tags": ["z", "a", "b", "c"],
+    "empty": {
+        "k": {
+            "v": true
+        }
+    },
+    "debug": false,
+}
+`)
+
+	assert.NoError(t, doc.Delete("listen"))
+	assert.NoError(t, doc.Delete("db", "pool"))
+	assert.NoError(t, doc.Delete("tags", 3))
+	assert.NoError(t, doc.Delete("tags", 0))
+	assert.NoError(t, doc.Delete("empty"))
+	assert.Equal(t, doc.String(), `// service config
+{
+    // where to listen
+    "db": {
+        "host": "localhost",
+        "port": 5432, /* default */
+        "user": "admin"
+    },
+    "tags": ["a", "b"],
+    "debug": false,
+}
+`)
+
+	err = doc.Set("db", "host", "x", 1)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	err = doc.Delete("missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	err = doc.Insert("db", 0, 1)
+	assert.Error(t, err)
+
+	doc, err = ParseDocument([]byte(`{"a":1,"b":[1,2]}`))
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Set("c", Array{1, "x"}))
+	assert.NoError(t, doc.Delete("a"))
+	assert.NoError(t, doc.Insert("b", 1, 9))
+	assert.Equal(t, doc.String(), `{"b":[1,9,2],"c":[1,"x"]}`)
+
+	doc, err = ParseDocument([]byte(`{"msg":"a: b, c","n":[1,2]}`))
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Set("m", 1))
+	assert.NoError(t, doc.Insert("n", 0, 0))
+	assert.Equal(t, doc.String(), `{"msg":"a: b, c","n":[0,1,2],"m":1}`)
+
+	doc, err = ParseDocument([]byte(`{"a": {}, "b": ["x,y"]}`))
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Set("a", "k", 1))
+	assert.NoError(t, doc.Insert("b", 1, 2))
+	assert.Equal(t, doc.String(), `{"a": {"k": 1}, "b": ["x,y", 2]}`)
+
+	_, err = ParseDocument([]byte(`{"a": 1`))
+	assert.Error(t, err)
+
+	doc, err = ParseDocument([]byte("{\r\n  \"a\": 1, // one\r\n  \"l\": []\r\n}\r\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Set("b", Object{"c": 2}))
+	assert.NoError(t, doc.Set("l", 0, 1))
+	assert.Equal(t, doc.String(), "{\r\n  \"a\": 1, // one\r\n  \"l\": [\r\n    1\r\n  ],\r\n  \"b\": {\r\n    \"c\": 2\r\n  }\r\n}\r\n")
+
+	doc, err = ParseDocument([]byte("{\"a\": 1 /* x\n y */}"))
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Set("b", 2))
+	assert.Equal(t, doc.String(), "{\"a\": 1, \"b\": 2 /* x\n y */}")
+}
+
+func TestDocument_Delete_Duplicate(t *testing.T) {
+	doc, err := ParseDocument([]byte("{\n  \"a\": 1,\n  \"b\": 2,\n  \"a\": 3\n}"))
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Delete("a"))
+	assert.Equal(t, doc.String(), "{\n  \"b\": 2\n}")
+	assert.False(t, doc.Exist("a"))
+}
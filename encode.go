@@ -0,0 +1,343 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EncodeOptions controls the JSON produced by MarshalWithOptions and
+// Encoder. Unlike encoding/json, the zero value does not escape HTML.
+type EncodeOptions struct {
+	// Prefix and Indent work like the arguments of json.MarshalIndent.
+	Prefix string
+	Indent string
+	// EscapeHTML escapes <, > and & as \u003c, \u003e and \u0026.
+	EscapeHTML bool
+	// SortKeys sorts the keys of Raw objects. Without it Raw keeps the
+	// document order; Object keys are always sorted.
+	SortKeys bool
+	// OmitNull drops object members whose value is null.
+	OmitNull bool
+	// FloatFormat and FloatPrecision reformat non-integer numbers with
+	// strconv.FormatFloat. The zero FloatFormat keeps the original spelling.
+	FloatFormat    byte
+	FloatPrecision int
+}
+
+type Encoder struct {
+	w    io.Writer
+	opts EncodeOptions
+}
+
+func NewEncoder(w io.Writer, opts EncodeOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// Encode writes v followed by a newline.
+func (e *Encoder) Encode(v interface{}) error {
+	data, err := MarshalWithOptions(v, e.opts)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+func MarshalWithOptions(v interface{}, opts EncodeOptions) ([]byte, error) {
+	switch opts.FloatFormat {
+	case 0, 'e', 'E', 'f', 'g', 'G':
+	default:
+		return nil, fmt.Errorf("jsons: invalid float format %q", opts.FloatFormat)
+	}
+	var e = encodeState{opts: opts}
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+type encodeState struct {
+	bytes.Buffer
+	opts  EncodeOptions
+	depth int
+}
+
+func (e *encodeState) newline() {
+	if e.opts.Prefix == "" && e.opts.Indent == "" {
+		return
+	}
+	e.WriteByte('\n')
+	e.WriteString(e.opts.Prefix)
+	for i := 0; i < e.depth; i++ {
+		e.WriteString(e.opts.Indent)
+	}
+}
+
+func (e *encodeState) colon() {
+	e.WriteByte(':')
+	if e.opts.Prefix != "" || e.opts.Indent != "" {
+		e.WriteByte(' ')
+	}
+}
+
+func (e *encodeState) encode(src interface{}) error {
+	switch v := src.(type) {
+	case Raw:
+		return e.encodeRaw(v)
+	case json.RawMessage:
+		return e.encodeRaw(Raw(v))
+	case Value:
+		return e.encode(v.value)
+	}
+
+	src, err := normalize(src)
+	if err != nil {
+		return err
+	}
+	switch v := src.(type) {
+	case nil:
+		e.WriteString("null")
+	case Bool:
+		e.WriteString(strconv.FormatBool(bool(v)))
+	case Number:
+		return e.number(v)
+	case String:
+		e.string(string(v))
+	case Array:
+		if len(v) == 0 {
+			e.WriteString("[]")
+			return nil
+		}
+		e.WriteByte('[')
+		e.depth++
+		for i, elem := range v {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+			e.newline()
+			if err = e.encode(elem); err != nil {
+				return err
+			}
+		}
+		e.depth--
+		e.newline()
+		e.WriteByte(']')
+	case Object:
+		var keys = make([]string, 0, len(v))
+		for key, elem := range v {
+			if e.opts.OmitNull && isNull(elem) {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			e.WriteString("{}")
+			return nil
+		}
+		sort.Strings(keys)
+		e.WriteByte('{')
+		e.depth++
+		for i, key := range keys {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+			e.newline()
+			e.string(key)
+			e.colon()
+			if err = e.encode(v[key]); err != nil {
+				return err
+			}
+		}
+		e.depth--
+		e.newline()
+		e.WriteByte('}')
+	}
+	return nil
+}
+
+func isNull(src interface{}) bool {
+	switch v := src.(type) {
+	case Raw:
+		return v.IsNull()
+	case json.RawMessage:
+		return Raw(v).IsNull()
+	}
+	src, _ = normalize(src)
+	return src == nil
+}
+
+func (e *encodeState) number(n Number) error {
+	if n == "" {
+		e.WriteByte('0')
+		return nil
+	}
+	if !json.Valid([]byte(n)) {
+		return fmt.Errorf("jsons: invalid number %q", string(n))
+	}
+	if e.opts.FloatFormat != 0 && strings.ContainsAny(string(n), ".eE") {
+		f, err := strconv.ParseFloat(string(n), 64)
+		if err != nil {
+			return fmt.Errorf("jsons: invalid number %q: %v", string(n), err)
+		}
+		e.WriteString(strconv.FormatFloat(f, e.opts.FloatFormat, e.opts.FloatPrecision, 64))
+		return nil
+	}
+	e.WriteString(string(n))
+	return nil
+}
+
+// string writes s quoted the way encoding/json does, with optional HTML
+// escaping.
+func (e *encodeState) string(s string) {
+	const hex = "0123456789abcdef"
+	e.WriteByte('"')
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				e.WriteByte('\\')
+				e.WriteByte(c)
+			case c == '\n':
+				e.WriteString(`\n`)
+			case c == '\r':
+				e.WriteString(`\r`)
+			case c == '\t':
+				e.WriteString(`\t`)
+			case c < 0x20 || e.opts.EscapeHTML && (c == '<' || c == '>' || c == '&'):
+				e.WriteString(`\u00`)
+				e.WriteByte(hex[c>>4])
+				e.WriteByte(hex[c&0xf])
+			default:
+				e.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			e.WriteString(`\ufffd`)
+		case r == '\u2028' || r == '\u2029':
+			e.WriteString(`\u202`)
+			e.WriteByte(hex[r&0xf])
+		default:
+			e.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	e.WriteByte('"')
+}
+
+// encodeRaw re-encodes a Raw document token by token so that its key order
+// and number spellings survive.
+func (e *encodeState) encodeRaw(r Raw) error {
+	if len(bytes.TrimSpace(r)) == 0 {
+		e.WriteString("null")
+		return nil
+	}
+	if e.opts.SortKeys {
+		val, err := Unmarshal(r)
+		if err != nil {
+			return err
+		}
+		return e.encode(val)
+	}
+	dec := json.NewDecoder(bytes.NewReader(r))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return e.rawToken(dec, tok)
+}
+
+func (e *encodeState) rawToken(dec *json.Decoder, tok json.Token) error {
+	switch v := tok.(type) {
+	case nil:
+		e.WriteString("null")
+	case bool:
+		e.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		return e.number(Number(v))
+	case string:
+		e.string(v)
+	case json.Delim:
+		switch v {
+		case '[':
+			return e.rawArray(dec)
+		case '{':
+			return e.rawObject(dec)
+		}
+		return fmt.Errorf("jsons: unexpected %v", v)
+	}
+	return nil
+}
+
+func (e *encodeState) rawArray(dec *json.Decoder) error {
+	var empty = true
+	e.WriteByte('[')
+	e.depth++
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if !empty {
+			e.WriteByte(',')
+		}
+		empty = false
+		e.newline()
+		if err = e.rawToken(dec, tok); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	if !empty {
+		e.newline()
+	}
+	e.WriteByte(']')
+	_, err := dec.Token()
+	return err
+}
+
+func (e *encodeState) rawObject(dec *json.Decoder) error {
+	var empty = true
+	e.WriteByte('{')
+	e.depth++
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil && e.opts.OmitNull {
+			continue
+		}
+		if !empty {
+			e.WriteByte(',')
+		}
+		empty = false
+		e.newline()
+		e.string(key.(string))
+		e.colon()
+		if err = e.rawToken(dec, tok); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	if !empty {
+		e.newline()
+	}
+	e.WriteByte('}')
+	_, err := dec.Token()
+	return err
+}
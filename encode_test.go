@@ -0,0 +1,54 @@
+package jsons
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestMarshalWithOptions(t *testing.T) {
+	val, err := Unmarshal([]byte(`{"url": "https://a.b/?x=1&y=<2>", "b": null, "a": [1.50, 2, {}], "c": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalWithOptions(val, EncodeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"a":[1.50,2,{}],"b":null,"c":[],"url":"https://a.b/?x=1&y=<2>"}`)
+
+	data, err = MarshalWithOptions(val, EncodeOptions{EscapeHTML: true, OmitNull: true})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"a":[1.50,2,{}],"c":[],"url":"https://a.b/?x=1\u0026y=\u003c2\u003e"}`)
+
+	data, err = MarshalWithOptions(val.Object(), EncodeOptions{Indent: "  ", EscapeHTML: true, FloatFormat: 'f', FloatPrecision: -1})
+	assert.NoError(t, err)
+	expected, _ := MarshalIdent(val, "", "  ")
+	assert.Equal(t, string(data), string(bytes.Replace(expected, []byte("1.50"), []byte("1.5"), 1)))
+
+	data, err = MarshalWithOptions(val.Array("a"), EncodeOptions{FloatFormat: 'e', FloatPrecision: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `[1.50e+00,2,{}]`)
+
+	raw := Raw(`{"z": 1, "a": {"y": null, "x": "\u2028"}, "m": [true]}`)
+	data, err = MarshalWithOptions(raw, EncodeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"z":1,"a":{"y":null,"x":"\u2028"},"m":[true]}`)
+
+	data, err = MarshalWithOptions(raw, EncodeOptions{OmitNull: true, Prefix: "#", Indent: "\t"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "{\n#\t\"z\": 1,\n#\t\"a\": {\n#\t\t\"x\": \"\\u2028\"\n#\t},\n#\t\"m\": [\n#\t\ttrue\n#\t]\n#}")
+
+	data, err = MarshalWithOptions(raw, EncodeOptions{SortKeys: true})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `{"a":{"x":"\u2028","y":null},"m":[true],"z":1}`)
+
+	var buf bytes.Buffer
+	assert.NoError(t, NewEncoder(&buf, EncodeOptions{}).Encode(Array{"<", Number("1")}))
+	assert.Equal(t, buf.String(), "[\"<\",1]\n")
+
+	_, err = MarshalWithOptions(Number("1x"), EncodeOptions{})
+	assert.Error(t, err)
+	_, err = MarshalWithOptions(val, EncodeOptions{FloatFormat: 'x'})
+	assert.Error(t, err)
+}
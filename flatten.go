@@ -0,0 +1,268 @@
+package jsons
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxFlatIndex is the largest array index a path may hold when a Flattener
+// unflattens, so that a single path such as "a[99999999]" cannot allocate a
+// huge Array.
+const MaxFlatIndex = 1<<16 - 1
+
+// Flattener maps nested values to and from Objects of leaf paths such as
+// "a.b.0.c", or "a.b[0].c" with Brackets. Keys holding the separator, a
+// bracket or a backslash are escaped with a backslash, as are numeric keys
+// in dot notation, so that every path maps back to the same keys.
+type Flattener struct {
+	// Separator joins keys, "." by default.
+	Separator string
+	// Brackets writes array indexes as "[0]" instead of as keys.
+	Brackets bool
+}
+
+// Flatten returns the leaves of the value at keys by dot notation path, as
+// in {"a.b.0.c": 1}, joining keys with sep ("." if empty).
+func (v Value) Flatten(sep string, keys ...interface{}) (Object, error) {
+	return Flattener{Separator: sep}.Flatten(v.Get(keys...))
+}
+
+// Unflatten rebuilds a tree from the dot notation paths Value.Flatten
+// returns.
+func Unflatten(obj Object, sep string) (Value, error) {
+	return Flattener{Separator: sep}.Unflatten(obj)
+}
+
+// Flatten returns the leaves of src by path. Empty Objects and Arrays are
+// leaves, and a scalar src is returned under the empty path.
+func (f Flattener) Flatten(src interface{}) (Object, error) {
+	var obj = make(Object)
+	err := f.walk("", src, func(path string, leaf interface{}) {
+		obj[path] = leaf
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Unflatten rebuilds the tree whose leaves obj holds by path. Array indexes
+// above MaxFlatIndex are rejected.
+func (f Flattener) Unflatten(obj Object) (Value, error) {
+	val, err := f.rebuild(obj)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{value: val}, nil
+}
+
+func (f Flattener) sep() string {
+	if f.Separator == "" {
+		return "."
+	}
+	return f.Separator
+}
+
+func (f Flattener) escape(key string) string {
+	var b strings.Builder
+	if !f.Brackets && key != "" && strings.Trim(key, "0123456789") == "" {
+		b.WriteByte('\\')
+	}
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' || f.Brackets && key[i] == '[':
+			b.WriteByte('\\')
+		case strings.HasPrefix(key[i:], f.sep()):
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
+
+func (f Flattener) join(path string, key interface{}) string {
+	switch key := key.(type) {
+	case int:
+		if f.Brackets {
+			return path + "[" + strconv.Itoa(key) + "]"
+		}
+		if path == "" {
+			return strconv.Itoa(key)
+		}
+		return path + f.sep() + strconv.Itoa(key)
+	case string:
+		if path == "" {
+			return f.escape(key)
+		}
+		return path + f.sep() + f.escape(key)
+	}
+	return path
+}
+
+// walk calls fn for every leaf of src in key order. Empty Objects and Arrays
+// are leaves.
+func (f Flattener) walk(path string, src interface{}, fn func(path string, leaf interface{})) error {
+	src, err := normalize(src)
+	if err != nil {
+		return err
+	}
+	switch v := src.(type) {
+	case Object:
+		if len(v) > 0 {
+			for _, key := range sortedKeys(v) {
+				if err = f.walk(f.join(path, key), v[key], fn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case Array:
+		if len(v) > 0 {
+			for i, elem := range v {
+				if err = f.walk(f.join(path, i), elem, fn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	fn(path, src)
+	return nil
+}
+
+// parse splits a path into the keys Get and Set take: strings for object
+// keys and ints for array indexes.
+func (f Flattener) parse(path string) ([]interface{}, error) {
+	var keys []interface{}
+	var cur strings.Builder
+	var escaped, closed bool
+	var push = func() {
+		var key = cur.String()
+		if n, err := strconv.Atoi(key); err == nil && !f.Brackets && !escaped && n >= 0 && key[0] != '+' {
+			keys = append(keys, n)
+		} else {
+			keys = append(keys, key)
+		}
+		cur.Reset()
+		escaped = false
+	}
+	if path == "" {
+		return nil, nil
+	}
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '\\':
+			if i+1 == len(path) {
+				return nil, fmt.Errorf("jsons: path %q ends with a backslash", path)
+			}
+			if strings.HasPrefix(path[i+1:], f.sep()) {
+				cur.WriteString(f.sep())
+				i += 1 + len(f.sep())
+			} else {
+				cur.WriteByte(path[i+1])
+				i += 2
+			}
+			escaped, closed = true, false
+		case strings.HasPrefix(path[i:], f.sep()):
+			if !closed {
+				push()
+			}
+			i += len(f.sep())
+			closed = false
+		case f.Brackets && path[i] == '[':
+			var end = strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsons: path %q has an unclosed bracket", path)
+			}
+			n, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("jsons: path %q has an invalid index %q", path, path[i+1:i+end])
+			}
+			if !closed && (cur.Len() > 0 || escaped || i > 0) {
+				push()
+			}
+			keys = append(keys, n)
+			i += end + 1
+			closed = true
+			if i < len(path) && path[i] != '[' && !strings.HasPrefix(path[i:], f.sep()) {
+				return nil, fmt.Errorf("jsons: path %q has text after an index", path)
+			}
+		default:
+			cur.WriteByte(path[i])
+			i++
+			closed = false
+		}
+	}
+	if !closed {
+		push()
+	}
+	return keys, nil
+}
+
+func (f Flattener) rebuild(flat map[string]interface{}) (interface{}, error) {
+	var paths = make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var root interface{}
+	for _, path := range paths {
+		keys, err := f.parse(path)
+		if err != nil {
+			return nil, err
+		}
+		if root, err = setPath(root, keys, flat[path], MaxFlatIndex); err != nil {
+			return nil, fmt.Errorf("jsons: path %q %v", path, err)
+		}
+	}
+	return root, nil
+}
+
+// setPath sets val at keys below node, creating Objects and Arrays on the
+// way. Indexes above limit are rejected so a single path cannot allocate a
+// huge Array.
+func setPath(node interface{}, keys []interface{}, val interface{}, limit int) (interface{}, error) {
+	if len(keys) == 0 {
+		if node != nil {
+			return nil, fmt.Errorf("conflicts with another path")
+		}
+		return val, nil
+	}
+	switch key := keys[0].(type) {
+	case int:
+		arr, ok := node.([]interface{})
+		if !ok && node != nil {
+			return nil, fmt.Errorf("indexes a non-array")
+		}
+		if key > limit {
+			return nil, fmt.Errorf("index %d out of range", key)
+		}
+		for len(arr) <= key {
+			arr = append(arr, nil)
+		}
+		elem, err := setPath(arr[key], keys[1:], val, limit)
+		if err != nil {
+			return nil, err
+		}
+		arr[key] = elem
+		return arr, nil
+	case string:
+		obj, ok := node.(map[string]interface{})
+		if !ok && node != nil {
+			return nil, fmt.Errorf("keys a non-object")
+		}
+		if obj == nil {
+			obj = make(map[string]interface{})
+		}
+		elem, err := setPath(obj[key], keys[1:], val, limit)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = elem
+		return obj, nil
+	}
+	return nil, fmt.Errorf("invalid key %v", keys[0])
+}
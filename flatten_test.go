@@ -0,0 +1,60 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_Flatten(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"a": {"b": [{"c": 1}, 2]}, "x.y": true, "0": "zero", "e": {}, "n": null, "back\\slash": []}`))
+	flat, err := val.Flatten(".")
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: flat}.JSONString(), `{"\\0":"zero","a.b.0.c":1,"a.b.1":2,"back\\\\slash":[],"e":{},"n":null,"x\\.y":true}`)
+
+	back, err := Unflatten(flat, ".")
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), val.JSONString())
+
+	flat, err = val.Flatten("__", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: flat}.JSONString(), `{"b__0__c":1,"b__1":2}`)
+	_, err = Value{value: Object{"c": make(chan int)}}.Flatten(".")
+	assert.Error(t, err)
+
+	f := Flattener{Brackets: true}
+	flat, err = f.Flatten(val)
+	assert.NoError(t, err)
+	assert.Equal(t, flat.Number("a.b[0].c"), Number("1"))
+	assert.Equal(t, flat.String("0"), "zero")
+	back, err = f.Unflatten(flat)
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), val.JSONString())
+
+	flat, err = f.Flatten(Number("1"))
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: flat}.JSONString(), `{"":1}`)
+	back, err = f.Unflatten(flat)
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), `1`)
+
+	back, err = f.Unflatten(Object{"a[65535]": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, back.Len("a"), MaxFlatIndex+1)
+	_, err = f.Unflatten(Object{"a[65536]": 1})
+	assert.Error(t, err)
+
+	for _, obj := range []Object{
+		{"a": 1, "a.b": 2},
+		{"a.0": 1, "a.b": 2},
+		{"a\\": 1},
+		{"a.99999999": 1},
+	} {
+		_, err = Unflatten(obj, ".")
+		assert.Error(t, err)
+	}
+	for _, obj := range []Object{{"a[x]": 1}, {"a[0": 1}, {"a[0]b": 1}} {
+		_, err = f.Unflatten(obj)
+		assert.Error(t, err)
+	}
+}
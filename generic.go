@@ -0,0 +1,30 @@
+package jsons
+
+import (
+	"reflect"
+)
+
+// Get decodes the value at keys into a T, reflecting directly from the
+// in-memory tree instead of going through JSON bytes.
+func Get[T any](v Value, keys ...interface{}) (T, error) {
+	var t T
+	var d decoder
+	err := d.decodeAt(v, keys, reflect.ValueOf(&t).Elem())
+	return t, err
+}
+
+// As is like Get but returns the zero T when decoding fails.
+func As[T any](v Value, keys ...interface{}) T {
+	t, _ := Get[T](v, keys...)
+	return t
+}
+
+// Map decodes the object at keys into a map of T.
+func Map[T any](v Value, keys ...interface{}) (map[string]T, error) {
+	return Get[map[string]T](v, keys...)
+}
+
+// Slice decodes the array at keys into a slice of T.
+func Slice[T any](v Value, keys ...interface{}) ([]T, error) {
+	return Get[[]T](v, keys...)
+}
@@ -0,0 +1,78 @@
+package jsons
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestGet(t *testing.T) {
+	val, err := Unmarshal([]byte(`{
+		"tags": ["a", "b"],
+		"ids": [1, 2, 9007199254740993],
+		"scores": {"x": 1.5, "y": 2},
+		"user": {
+			"name": "seven",
+			"Age": 18,
+			"born": "2004-01-02T03:04:05Z",
+			"extra": {"n": 1.10},
+			"embedded": true,
+			"count": "42"
+		},
+		"bad": [1, "x"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := Slice[string](val, "tags")
+	assert.NoError(t, err)
+	assert.Equal(t, tags, []string{"a", "b"})
+
+	ids, err := Slice[int64](val, "ids")
+	assert.NoError(t, err)
+	assert.Equal(t, ids, []int64{1, 2, 9007199254740993})
+
+	scores, err := Map[float64](val, "scores")
+	assert.NoError(t, err)
+	assert.Equal(t, scores, map[string]float64{"x": 1.5, "y": 2})
+
+	type Embedded struct {
+		Embedded bool `json:"embedded"`
+	}
+	type User struct {
+		Embedded
+		Name  string    `json:"name"`
+		Age   int       `json:"age"`
+		Born  time.Time `json:"born"`
+		Extra Value     `json:"extra"`
+		Count int       `json:"count,string"`
+		Skip  string    `json:"-"`
+	}
+	user, err := Get[User](val, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, user.Name, "seven")
+	assert.Equal(t, user.Age, 18)
+	assert.True(t, user.Born.Equal(time.Date(2004, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(t, user.Extra.Number("n"), Number("1.10"))
+	assert.Equal(t, user.Embedded.Embedded, true)
+	assert.Equal(t, user.Count, 42)
+
+	user.Extra.Set("n", 2)
+	assert.Equal(t, val.Number("user", "extra", "n"), Number("1.10"))
+
+	ptr, err := Get[*User](val, "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, ptr)
+
+	_, err = Slice[int](val, "bad")
+	assert.Error(t, err)
+	_, err = Get[int](val, "tags")
+	assert.Error(t, err)
+
+	assert.Equal(t, As[string](val, "user", "name"), "seven")
+	assert.Equal(t, As[string](val, "user", "Age"), "")
+	assert.Equal(t, As[interface{}](val, "scores"), map[string]interface{}{"x": 1.5, "y": float64(2)})
+	assert.Equal(t, As[Array](val, "tags"), Array{"a", "b"})
+}
@@ -1,9 +1,19 @@
 module github.com/zooyer/jsons
 
-go 1.16
+go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/google/go-cmp v0.5.8
 	github.com/tj/assert v0.0.3
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/gorm v1.23.5
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/testify v1.6.1 // indirect
+)
@@ -0,0 +1,191 @@
+package jsons
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the body limit of ReadRequest when
+// ReadOptions.MaxBytes is zero.
+const DefaultMaxBodyBytes = 1 << 20
+
+var (
+	ErrEmptyBody   = errors.New("empty body")
+	ErrContentType = errors.New("unsupported content type")
+)
+
+// ReadOptions configures ReadRequest.
+type ReadOptions struct {
+	// MaxBytes limits the body, DefaultMaxBodyBytes if zero and unlimited
+	// if negative.
+	MaxBytes int64
+	// ContentTypes lists the accepted media types. By default
+	// application/json and any "+json" type are accepted.
+	ContentTypes []string
+	// AllowEmpty reads an empty body as null instead of failing with
+	// ErrEmptyBody.
+	AllowEmpty bool
+	// AllowForm also reads application/x-www-form-urlencoded bodies, with
+	// FromURLValuesWithOptions and Form.
+	AllowForm bool
+	Form      URLOptions
+	// Unmarshal limits the document; numbers are always kept as Number.
+	Unmarshal UnmarshalOptions
+}
+
+// RequestError is returned by ReadRequest with the status a handler should
+// respond with: 413 for a body over the limit, 415 for an unsupported
+// content type and 400 otherwise.
+type RequestError struct {
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("jsons: http: %v", e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// ReadRequest decodes the body of r into a Value.
+func ReadRequest(r *http.Request, opts ReadOptions) (Value, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return Value{}, &RequestError{Status: http.StatusUnsupportedMediaType, Err: ErrContentType}
+	}
+	var form = opts.AllowForm && mediaType == "application/x-www-form-urlencoded"
+	if !form && !opts.accepts(mediaType) {
+		return Value{}, &RequestError{Status: http.StatusUnsupportedMediaType, Err: fmt.Errorf("%w %q", ErrContentType, mediaType)}
+	}
+
+	var body io.Reader = http.NoBody
+	if r.Body != nil {
+		body = r.Body
+	}
+	var limit = opts.MaxBytes
+	if limit == 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	if limit > 0 {
+		body = io.LimitReader(body, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return Value{}, &RequestError{Status: http.StatusBadRequest, Err: err}
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return Value{}, &RequestError{Status: http.StatusRequestEntityTooLarge, Err: ErrMaxBytes}
+	}
+
+	if form {
+		vals, err := url.ParseQuery(string(data))
+		if err != nil {
+			return Value{}, &RequestError{Status: http.StatusBadRequest, Err: err}
+		}
+		val, err := FromURLValuesWithOptions(vals, opts.Form)
+		if err != nil {
+			return Value{}, &RequestError{Status: http.StatusBadRequest, Err: err}
+		}
+		return val, nil
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		if opts.AllowEmpty {
+			return Value{}, nil
+		}
+		return Value{}, &RequestError{Status: http.StatusBadRequest, Err: ErrEmptyBody}
+	}
+	val, err := UnmarshalWithOptions(data, opts.Unmarshal)
+	if err != nil {
+		return Value{}, &RequestError{Status: http.StatusBadRequest, Err: err}
+	}
+	return val, nil
+}
+
+func (o ReadOptions) accepts(mediaType string) bool {
+	if o.ContentTypes == nil {
+		return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	}
+	for _, t := range o.ContentTypes {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteOptions configures WriteResponseWithOptions.
+type WriteOptions struct {
+	Encode EncodeOptions
+	// PrettyParam names the query parameter that indents the response,
+	// "pretty" by default; any value but "false" or "0" enables it.
+	PrettyParam string
+	// Indent is used for pretty responses, two spaces by default.
+	Indent string
+	// ContentType is "application/json; charset=utf-8" by default.
+	ContentType string
+}
+
+// WriteResponse writes v, such as a Value, Object, Array or Raw, as a JSON
+// response with the given status.
+func WriteResponse(w http.ResponseWriter, status int, v interface{}) error {
+	return WriteResponseWithOptions(w, nil, status, v, WriteOptions{})
+}
+
+// WriteResponseWithOptions writes v as a JSON response to r, which may be
+// nil. The body is encoded before anything is written, so on error the
+// handler can still respond otherwise. No body is written for HEAD requests
+// or for statuses that do not allow one.
+func WriteResponseWithOptions(w http.ResponseWriter, r *http.Request, status int, v interface{}, opts WriteOptions) error {
+	var enc = opts.Encode
+	if r != nil && opts.pretty(r) {
+		enc.Indent = opts.Indent
+		if enc.Indent == "" {
+			enc.Indent = "  "
+		}
+	}
+	data, err := MarshalWithOptions(v, enc)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	var header = w.Header()
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	} else {
+		header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+	if status == http.StatusNoContent || status == http.StatusNotModified || status >= 100 && status < 200 {
+		header.Del("Content-Type")
+		w.WriteHeader(status)
+		return nil
+	}
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r != nil && r.Method == http.MethodHead {
+		return nil
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (o WriteOptions) pretty(r *http.Request) bool {
+	var param = o.PrettyParam
+	if param == "" {
+		param = "pretty"
+	}
+	if r.URL == nil {
+		return false
+	}
+	vals, ok := r.URL.Query()[param]
+	return ok && vals[0] != "false" && vals[0] != "0"
+}
@@ -0,0 +1,95 @@
+package jsons
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestReadRequest(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return r
+	}
+
+	val, err := ReadRequest(newRequest("application/json; charset=utf-8", `{"id": 12345678901234567890}`), ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, val.Number("id"), Number("12345678901234567890"))
+
+	val, err = ReadRequest(newRequest("application/merge-patch+json", `[1]`), ReadOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `[1]`)
+
+	val, err = ReadRequest(newRequest("application/x-www-form-urlencoded", `a[b]=1&tags[]=x`), ReadOptions{AllowForm: true})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"a":{"b":"1"},"tags":["x"]}`)
+
+	val, err = ReadRequest(newRequest("application/json", ``), ReadOptions{AllowEmpty: true})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `null`)
+
+	for _, c := range []struct {
+		r      *http.Request
+		opts   ReadOptions
+		status int
+		err    error
+	}{
+		{newRequest("", `{}`), ReadOptions{}, http.StatusUnsupportedMediaType, ErrContentType},
+		{newRequest("text/plain", `{}`), ReadOptions{}, http.StatusUnsupportedMediaType, ErrContentType},
+		{newRequest("application/json", `{}`), ReadOptions{ContentTypes: []string{"application/vnd.api"}}, http.StatusUnsupportedMediaType, ErrContentType},
+		{newRequest("application/x-www-form-urlencoded", `a=1`), ReadOptions{}, http.StatusUnsupportedMediaType, ErrContentType},
+		{newRequest("application/json", `[1, 2, 3]`), ReadOptions{MaxBytes: 5}, http.StatusRequestEntityTooLarge, ErrMaxBytes},
+		{newRequest("application/json", " \n"), ReadOptions{}, http.StatusBadRequest, ErrEmptyBody},
+		{newRequest("application/json", `{} {}`), ReadOptions{}, http.StatusBadRequest, ErrTrailingData},
+		{newRequest("application/json", `[[1]]`), ReadOptions{Unmarshal: UnmarshalOptions{MaxDepth: 1}}, http.StatusBadRequest, ErrMaxDepth},
+	} {
+		_, err = ReadRequest(c.r, c.opts)
+		var reqErr *RequestError
+		assert.True(t, errors.As(err, &reqErr))
+		assert.Equal(t, reqErr.Status, c.status)
+		assert.True(t, errors.Is(err, c.err), err.Error())
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"b": 1.50, "a": "<x>"}`))
+
+	w := httptest.NewRecorder()
+	assert.NoError(t, WriteResponse(w, http.StatusCreated, val))
+	assert.Equal(t, w.Code, http.StatusCreated)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	assert.Equal(t, w.Header().Get("Content-Length"), "21")
+	assert.Equal(t, w.Body.String(), "{\"a\":\"<x>\",\"b\":1.50}\n")
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/?pretty", nil)
+	assert.NoError(t, WriteResponseWithOptions(w, r, http.StatusOK, Array{1}, WriteOptions{}))
+	assert.Equal(t, w.Body.String(), "[\n  1\n]\n")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/?pretty=0", nil)
+	assert.NoError(t, WriteResponseWithOptions(w, r, http.StatusOK, Array{1}, WriteOptions{}))
+	assert.Equal(t, w.Body.String(), "[1]\n")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodHead, "/", nil)
+	assert.NoError(t, WriteResponseWithOptions(w, r, http.StatusOK, Array{1}, WriteOptions{}))
+	assert.Equal(t, w.Header().Get("Content-Length"), "4")
+	assert.Equal(t, w.Body.Len(), 0)
+
+	w = httptest.NewRecorder()
+	assert.NoError(t, WriteResponse(w, http.StatusNoContent, nil))
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.Equal(t, w.Body.Len(), 0)
+
+	w = httptest.NewRecorder()
+	assert.Error(t, WriteResponse(w, http.StatusOK, Raw(`{`)))
+	assert.Equal(t, len(w.Header()), 0)
+}
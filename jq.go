@@ -0,0 +1,2087 @@
+package jsons
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// JQ is a compiled filter in a subset of the jq language; filters using
+// syntax or builtins outside it fail to compile. It is safe for concurrent
+// use.
+//
+// Unlike jq, numbers keep their spelling unless computed, arithmetic is exact
+// with division rounded to 34 decimal places, computed numbers may not have
+// more than 100 digits, and object members are visited in key order.
+type JQ struct {
+	src  string
+	root jqNode
+}
+
+// CompileJQ parses a jq filter.
+func CompileJQ(src string) (*JQ, error) {
+	var p = jqParser{src: src}
+	root, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if p.space(); p.pos < len(p.src) {
+		return nil, p.unexpected()
+	}
+	return &JQ{src: src, root: root}, nil
+}
+
+func (q *JQ) String() string {
+	return q.src
+}
+
+// Run applies the filter to v and returns its outputs.
+func (q *JQ) Run(v Value) ([]Value, error) {
+	in, err := normalize(v.value)
+	if err != nil {
+		return nil, err
+	}
+	var out = make([]Value, 0)
+	err = q.root.eval(nil, in, func(x interface{}) error {
+		out = append(out, Value{value: x})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// maxJQDepth bounds the nesting of a filter.
+const maxJQDepth = 1000
+
+type jqParser struct {
+	src   string
+	pos   int
+	depth int
+	vars  []string // variables bound by enclosing "as", innermost last
+}
+
+func (p *jqParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("jsons: jq: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jqParser) unexpected() error {
+	if p.pos >= len(p.src) {
+		return p.errorf("unexpected end of filter")
+	}
+	var end = p.pos + 1
+	for end < len(p.src) && isJQIdent(p.src[end], true) && isJQIdent(p.src[p.pos], true) {
+		end++
+	}
+	return p.errorf("unexpected %q", p.src[p.pos:end])
+}
+
+// space skips white space and comments.
+func (p *jqParser) space() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isJQIdent(c byte, inner bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || inner && c >= '0' && c <= '9'
+}
+
+// consume skips s if it comes next.
+func (p *jqParser) consume(s string) bool {
+	if p.space(); strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jqParser) expect(s string) error {
+	if !p.consume(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+// keyword skips the word if it comes next.
+func (p *jqParser) keyword(word string) bool {
+	p.space()
+	var end = p.pos + len(word)
+	if strings.HasPrefix(p.src[p.pos:], word) && (end == len(p.src) || !isJQIdent(p.src[end], true)) {
+		p.pos = end
+		return true
+	}
+	return false
+}
+
+func (p *jqParser) ident() string {
+	var start = p.pos
+	if p.pos < len(p.src) && isJQIdent(p.src[p.pos], false) {
+		for p.pos < len(p.src) && isJQIdent(p.src[p.pos], true) {
+			p.pos++
+		}
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *jqParser) pipe() (jqNode, error) {
+	if p.depth++; p.depth > maxJQDepth {
+		return nil, p.errorf("filter nested too deeply")
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.comma()
+	if err != nil {
+		return nil, err
+	}
+	if p.keyword("as") {
+		if !p.consume("$") {
+			return nil, p.errorf("expected a variable after as")
+		}
+		var name = p.ident()
+		if name == "" {
+			return nil, p.unexpected()
+		}
+		if err = p.expect("|"); err != nil {
+			return nil, err
+		}
+		p.vars = append(p.vars, name)
+		body, err := p.pipe()
+		p.vars = p.vars[:len(p.vars)-1]
+		if err != nil {
+			return nil, err
+		}
+		return jqAs{term: left, name: name, body: body}, nil
+	}
+	if p.consume("|") {
+		right, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return jqPipe{left, right}, nil
+	}
+	return left, nil
+}
+
+func (p *jqParser) comma() (jqNode, error) {
+	left, err := p.alt()
+	for err == nil && p.consume(",") {
+		var right jqNode
+		if right, err = p.alt(); err == nil {
+			left = jqComma{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *jqParser) alt() (jqNode, error) {
+	left, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.consume("//") {
+		right, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		return jqAlt{left, right}, nil
+	}
+	return left, nil
+}
+
+func (p *jqParser) or() (jqNode, error) {
+	left, err := p.and()
+	for err == nil && p.keyword("or") {
+		var right jqNode
+		if right, err = p.and(); err == nil {
+			left = jqLogic{"or", left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *jqParser) and() (jqNode, error) {
+	left, err := p.compare()
+	for err == nil && p.keyword("and") {
+		var right jqNode
+		if right, err = p.compare(); err == nil {
+			left = jqLogic{"and", left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *jqParser) compare() (jqNode, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			return jqBinary{op, left, right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *jqParser) additive() (jqNode, error) {
+	left, err := p.multiplicative()
+	for err == nil {
+		var op string
+		switch {
+		case p.consume("+"):
+			op = "+"
+		case p.consume("-"):
+			op = "-"
+		default:
+			return left, nil
+		}
+		var right jqNode
+		if right, err = p.multiplicative(); err == nil {
+			left = jqBinary{op, left, right}
+		}
+	}
+	return nil, err
+}
+
+func (p *jqParser) multiplicative() (jqNode, error) {
+	left, err := p.unary()
+	for err == nil {
+		var op string
+		switch p.space(); {
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			return left, nil
+		case p.consume("*"):
+			op = "*"
+		case p.consume("/"):
+			op = "/"
+		case p.consume("%"):
+			op = "%"
+		default:
+			return left, nil
+		}
+		var right jqNode
+		if right, err = p.unary(); err == nil {
+			left = jqBinary{op, left, right}
+		}
+	}
+	return nil, err
+}
+
+func (p *jqParser) unary() (jqNode, error) {
+	if p.consume("-") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return jqNeg{x}, nil
+	}
+	return p.postfix()
+}
+
+func (p *jqParser) postfix() (jqNode, error) {
+	term, err := p.primary()
+	for err == nil {
+		p.space()
+		var rest = p.src[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "?"):
+			p.pos++
+			term = jqTry{body: term}
+		case strings.HasPrefix(rest, ".") && len(rest) > 1 && (isJQIdent(rest[1], false) || rest[1] == '"'):
+			p.pos++
+			var key jqNode
+			if key, err = p.fieldName(); err == nil {
+				term = jqIndex{term, key}
+			}
+		case strings.HasPrefix(rest, ".["):
+			p.pos++
+			term, err = p.bracket(term)
+		case strings.HasPrefix(rest, "["):
+			term, err = p.bracket(term)
+		default:
+			return term, nil
+		}
+	}
+	return nil, err
+}
+
+// fieldName parses the name after a dot.
+func (p *jqParser) fieldName() (jqNode, error) {
+	if p.src[p.pos] == '"' {
+		return p.stringLit()
+	}
+	return jqLiteral{String(p.ident())}, nil
+}
+
+// bracket parses [], [i] or [i:j] after term.
+func (p *jqParser) bracket(term jqNode) (jqNode, error) {
+	p.pos++
+	if p.consume("]") {
+		return jqIterate{term}, nil
+	}
+	var from, to jqNode
+	var err error
+	if !p.consume(":") {
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if !p.consume(":") {
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			return jqIndex{term, from}, nil
+		}
+	}
+	if !p.consume("]") {
+		if to, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	return jqSlice{term, from, to}, nil
+}
+
+func (p *jqParser) primary() (jqNode, error) {
+	p.space()
+	if p.pos >= len(p.src) {
+		return nil, p.unexpected()
+	}
+	var rest = p.src[p.pos:]
+	switch c := rest[0]; {
+	case strings.HasPrefix(rest, ".."):
+		p.pos += 2
+		return jqRecurse{}, nil
+	case c == '.':
+		p.pos++
+		if p.pos < len(p.src) && (isJQIdent(p.src[p.pos], false) || p.src[p.pos] == '"') {
+			key, err := p.fieldName()
+			if err != nil {
+				return nil, err
+			}
+			return jqIndex{jqIdentity{}, key}, nil
+		}
+		return jqIdentity{}, nil
+	case c == '$':
+		return p.variable()
+	case c >= '0' && c <= '9':
+		return p.number()
+	case c == '"':
+		return p.stringLit()
+	case c == '(':
+		p.pos++
+		x, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	case c == '[':
+		p.pos++
+		if p.consume("]") {
+			return jqArray{}, nil
+		}
+		x, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("]"); err != nil {
+			return nil, err
+		}
+		return jqArray{x}, nil
+	case c == '{':
+		return p.object()
+	case isJQIdent(c, false):
+		return p.word()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *jqParser) number() (jqNode, error) {
+	var start = p.pos
+	var digits = func() {
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+	}
+	digits()
+	if p.pos+1 < len(p.src) && p.src[p.pos] == '.' && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	var n = Number(p.src[start:p.pos])
+	if _, err := n.Rat(); err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number %q", string(n))
+	}
+	return jqLiteral{n}, nil
+}
+
+// stringLit parses a string literal, which may interpolate filters.
+func (p *jqParser) stringLit() (jqNode, error) {
+	var parts []interface{}
+	var seg strings.Builder
+	var flush = func() error {
+		if seg.Len() == 0 {
+			return nil
+		}
+		var s string
+		if err := json.Unmarshal([]byte(`"`+seg.String()+`"`), &s); err != nil {
+			return p.errorf("invalid string")
+		}
+		parts = append(parts, s)
+		seg.Reset()
+		return nil
+	}
+	p.pos++
+	for {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated string")
+		}
+		switch c := p.src[p.pos]; {
+		case c == '"':
+			p.pos++
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			switch {
+			case len(parts) == 0:
+				return jqLiteral{String("")}, nil
+			case len(parts) == 1:
+				if s, ok := parts[0].(string); ok {
+					return jqLiteral{String(s)}, nil
+				}
+			}
+			return jqString{parts}, nil
+		case c == '\\' && strings.HasPrefix(p.src[p.pos:], `\(`):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			p.pos += 2
+			x, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			parts = append(parts, x)
+		case c == '\\' && p.pos+1 < len(p.src):
+			seg.WriteString(p.src[p.pos : p.pos+2])
+			p.pos += 2
+		default:
+			seg.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *jqParser) object() (jqNode, error) {
+	p.pos++
+	var obj jqObject
+	if p.consume("}") {
+		return obj, nil
+	}
+	for {
+		var key, val jqNode
+		var err error
+		p.space()
+		switch rest := p.src[p.pos:]; {
+		case strings.HasPrefix(rest, "$"):
+			if val, err = p.variable(); err != nil {
+				return nil, err
+			}
+			key = jqLiteral{String(val.(jqVar).name)}
+		case strings.HasPrefix(rest, `"`):
+			if key, err = p.stringLit(); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(rest, "("):
+			p.pos++
+			if key, err = p.pipe(); err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+		case rest != "" && isJQIdent(rest[0], false):
+			key = jqLiteral{String(p.ident())}
+		default:
+			return nil, p.unexpected()
+		}
+		if val == nil {
+			if p.consume(":") {
+				if val, err = p.alt(); err != nil {
+					return nil, err
+				}
+			} else if _, ok := key.(jqLiteral); ok {
+				val = jqIndex{jqIdentity{}, key}
+			} else {
+				return nil, p.errorf("expected %q", ":")
+			}
+		}
+		obj = append(obj, [2]jqNode{key, val})
+		if p.consume("}") {
+			return obj, nil
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// variable parses a reference to a variable, which an enclosing "as" must
+// bind.
+func (p *jqParser) variable() (jqNode, error) {
+	var start = p.pos
+	p.pos++
+	var name = p.ident()
+	if name == "" {
+		return nil, p.unexpected()
+	}
+	for _, bound := range p.vars {
+		if bound == name {
+			return jqVar{name}, nil
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("$%s is not defined", name)
+}
+
+// word parses a keyword construct, a literal or a function call.
+func (p *jqParser) word() (jqNode, error) {
+	var start = p.pos
+	switch name := p.ident(); name {
+	case "true":
+		return jqLiteral{Bool(true)}, nil
+	case "false":
+		return jqLiteral{Bool(false)}, nil
+	case "null":
+		return jqLiteral{nil}, nil
+	case "if":
+		return p.ifRest()
+	case "try":
+		body, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		var try = jqTry{body: body}
+		if p.keyword("catch") {
+			if try.catch, err = p.postfix(); err != nil {
+				return nil, err
+			}
+		}
+		return try, nil
+	case "then", "elif", "else", "end", "as", "catch", "and", "or":
+		p.pos = start
+		return nil, p.unexpected()
+	default:
+		var args []jqNode
+		if p.consume("(") {
+			for {
+				arg, err := p.pipe()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.consume(")") {
+					break
+				}
+				if err = p.expect(";"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		fn, ok := jqBuiltins[fmt.Sprintf("%s/%d", name, len(args))]
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("unknown function %s/%d", name, len(args))
+		}
+		return jqCall{fn, args}, nil
+	}
+}
+
+// ifRest parses the rest of an if or elif.
+func (p *jqParser) ifRest() (jqNode, error) {
+	cond, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword("then") {
+		return nil, p.errorf("expected then")
+	}
+	then, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	var node = jqIf{cond: cond, then: then}
+	switch {
+	case p.keyword("elif"):
+		node.els, err = p.ifRest()
+		return node, err
+	case p.keyword("else"):
+		if node.els, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	if !p.keyword("end") {
+		return nil, p.errorf("expected end")
+	}
+	return node, nil
+}
+
+// jqEnv binds variables.
+type jqEnv struct {
+	name string
+	val  interface{}
+	next *jqEnv
+}
+
+// jqNode evaluates a filter, calling emit with every normalized output.
+type jqNode interface {
+	eval(env *jqEnv, in interface{}, emit func(interface{}) error) error
+}
+
+type (
+	jqIdentity struct{}
+	jqRecurse  struct{}
+	jqLiteral  struct{ val interface{} }
+	jqVar      struct{ name string }
+	jqIndex    struct{ term, key jqNode }
+	jqSlice    struct{ term, from, to jqNode }
+	jqIterate  struct{ term jqNode }
+	jqTry      struct{ body, catch jqNode }
+	jqArray    struct{ body jqNode }
+	jqObject   [][2]jqNode
+	jqString   struct{ parts []interface{} }
+	jqPipe     struct{ left, right jqNode }
+	jqComma    struct{ left, right jqNode }
+	jqAlt      struct{ left, right jqNode }
+	jqLogic    struct {
+		op          string
+		left, right jqNode
+	}
+	jqBinary struct {
+		op          string
+		left, right jqNode
+	}
+	jqNeg struct{ x jqNode }
+	jqIf  struct{ cond, then, els jqNode }
+	jqAs  struct {
+		term jqNode
+		name string
+		body jqNode
+	}
+	jqCall struct {
+		fn   jqBuiltin
+		args []jqNode
+	}
+)
+
+// jqError is raised by error/1 and caught with its value.
+type jqError struct {
+	val interface{}
+}
+
+func (e *jqError) Error() string {
+	if s, ok := e.val.(String); ok {
+		return "jsons: jq: " + string(s)
+	}
+	return "jsons: jq: " + jqJSON(e.val) + " (not a string)"
+}
+
+func jqErrorf(format string, args ...interface{}) error {
+	return &jqError{String(fmt.Sprintf(format, args...))}
+}
+
+// jqStop ends an evaluation early; every use allocates its own.
+type jqStop struct{}
+
+func (*jqStop) Error() string {
+	return "jsons: jq: stopped"
+}
+
+func jqNorm(x interface{}) interface{} {
+	v, _ := normalize(x)
+	return v
+}
+
+func jqType(x interface{}) string {
+	if _, ok := x.(Bool); ok {
+		return "boolean"
+	}
+	return typeName(x)
+}
+
+func jqJSON(x interface{}) string {
+	data, err := MarshalWithOptions(x, EncodeOptions{})
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
+
+// jqDescribe names a value in error messages, as jq does.
+func jqDescribe(x interface{}) string {
+	var s = jqJSON(x)
+	if len(s) > 11 {
+		s = s[:10] + "..."
+	}
+	return fmt.Sprintf("%s (%s)", jqType(x), s)
+}
+
+func truthy(x interface{}) bool {
+	return x != nil && x != Bool(false)
+}
+
+func (jqIdentity) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return emit(in)
+}
+
+func (jqRecurse) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	if err := emit(in); err != nil {
+		return err
+	}
+	switch v := in.(type) {
+	case Array:
+		for _, elem := range v {
+			if err := (jqRecurse{}).eval(env, jqNorm(elem), emit); err != nil {
+				return err
+			}
+		}
+	case Object:
+		for _, key := range sortedKeys(v) {
+			if err := (jqRecurse{}).eval(env, jqNorm(v[key]), emit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n jqLiteral) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return emit(n.val)
+}
+
+func (n jqVar) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	for e := env; e != nil; e = e.next {
+		if e.name == n.name {
+			return emit(e.val)
+		}
+	}
+	return jqErrorf("$%s is not defined", n.name)
+}
+
+func (n jqIndex) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.term.eval(env, in, func(t interface{}) error {
+		return n.key.eval(env, in, func(k interface{}) error {
+			x, err := jqIndexValue(t, k)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		})
+	})
+}
+
+func jqInt(x interface{}) (int, bool) {
+	n, ok := x.(Number)
+	if !ok {
+		return 0, false
+	}
+	r, err := n.Rat()
+	if err != nil {
+		return 0, false
+	}
+	var i = new(big.Int).Div(r.Num(), r.Denom())
+	if !i.IsInt64() || i.Int64() != int64(int(i.Int64())) {
+		return 0, false
+	}
+	return int(i.Int64()), true
+}
+
+func jqIndexValue(t, k interface{}) (interface{}, error) {
+	switch t := t.(type) {
+	case nil:
+		switch k.(type) {
+		case String, Number, nil:
+			return nil, nil
+		}
+	case Object:
+		if k, ok := k.(String); ok {
+			return jqNorm(t[string(k)]), nil
+		}
+	case Array:
+		if i, ok := jqInt(k); ok {
+			if i < 0 {
+				i += len(t)
+			}
+			if i < 0 || i >= len(t) {
+				return nil, nil
+			}
+			return jqNorm(t[i]), nil
+		}
+	}
+	if k, ok := k.(String); ok {
+		return nil, jqErrorf("cannot index %s with %q", jqType(t), string(k))
+	}
+	return nil, jqErrorf("cannot index %s with %s", jqType(t), jqType(k))
+}
+
+func (n jqSlice) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	var bound = func(node jqNode, fn func(interface{}) error) error {
+		if node == nil {
+			return fn(nil)
+		}
+		return node.eval(env, in, fn)
+	}
+	return n.term.eval(env, in, func(t interface{}) error {
+		return bound(n.to, func(to interface{}) error {
+			return bound(n.from, func(from interface{}) error {
+				var length int
+				switch t := t.(type) {
+				case nil:
+					return emit(nil)
+				case Array:
+					length = len(t)
+				case String:
+					length = utf8.RuneCountInString(string(t))
+				default:
+					return jqErrorf("cannot slice %s", jqType(t))
+				}
+				var clamp = func(x interface{}, def int) (int, error) {
+					if x == nil {
+						return def, nil
+					}
+					i, ok := jqInt(x)
+					if !ok {
+						return 0, jqErrorf("slice bounds must be numbers")
+					}
+					if i < 0 {
+						i += length
+					}
+					if i < 0 {
+						i = 0
+					}
+					if i > length {
+						i = length
+					}
+					return i, nil
+				}
+				i, err := clamp(from, 0)
+				if err != nil {
+					return err
+				}
+				j, err := clamp(to, length)
+				if err != nil {
+					return err
+				}
+				if j < i {
+					j = i
+				}
+				if s, ok := t.(String); ok {
+					return emit(String([]rune(string(s))[i:j]))
+				}
+				return emit(append(Array{}, t.(Array)[i:j]...))
+			})
+		})
+	})
+}
+
+func (n jqIterate) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.term.eval(env, in, func(t interface{}) error {
+		return jqEach(t, func(x interface{}) error {
+			return emit(x)
+		})
+	})
+}
+
+// jqEach calls fn with the elements of an Array or the values of an Object.
+func jqEach(t interface{}, fn func(interface{}) error) error {
+	switch t := t.(type) {
+	case Array:
+		for _, elem := range t {
+			if err := fn(jqNorm(elem)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Object:
+		for _, key := range sortedKeys(t) {
+			if err := fn(jqNorm(t[key])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return jqErrorf("cannot iterate over %s", jqDescribe(t))
+}
+
+// guard runs node with emit, telling the errors emit returns from those of
+// node itself.
+func guard(node jqNode, env *jqEnv, in interface{}, emit func(interface{}) error) (own, downstream error) {
+	own = node.eval(env, in, func(x interface{}) error {
+		if err := emit(x); err != nil {
+			downstream = err
+			return err
+		}
+		return nil
+	})
+	if downstream != nil {
+		return nil, downstream
+	}
+	return own, nil
+}
+
+func (n jqTry) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	own, downstream := guard(n.body, env, in, emit)
+	if downstream != nil || own == nil || n.catch == nil {
+		return downstream
+	}
+	var msg interface{} = String(strings.TrimPrefix(own.Error(), "jsons: "))
+	if e, ok := own.(*jqError); ok {
+		msg = e.val
+	}
+	return n.catch.eval(env, msg, emit)
+}
+
+func (n jqArray) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	var arr = make(Array, 0)
+	if n.body != nil {
+		err := n.body.eval(env, in, func(x interface{}) error {
+			arr = append(arr, x)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return emit(arr)
+}
+
+func (n jqObject) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	var build func(i int, acc Object) error
+	build = func(i int, acc Object) error {
+		if i == len(n) {
+			return emit(acc)
+		}
+		return n[i][0].eval(env, in, func(k interface{}) error {
+			key, ok := k.(String)
+			if !ok {
+				return jqErrorf("object keys must be strings, not %s", jqType(k))
+			}
+			return n[i][1].eval(env, in, func(v interface{}) error {
+				var obj = make(Object, len(acc)+1)
+				for k, v := range acc {
+					obj[k] = v
+				}
+				obj[string(key)] = v
+				return build(i+1, obj)
+			})
+		})
+	}
+	return build(0, Object{})
+}
+
+func (n jqString) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	var build func(i int, acc string) error
+	build = func(i int, acc string) error {
+		if i == len(n.parts) {
+			return emit(String(acc))
+		}
+		switch part := n.parts[i].(type) {
+		case string:
+			return build(i+1, acc+part)
+		case jqNode:
+			return part.eval(env, in, func(x interface{}) error {
+				return build(i+1, acc+jqToString(x))
+			})
+		}
+		return nil
+	}
+	return build(0, "")
+}
+
+// jqString_ is tostring.
+func jqToString(x interface{}) string {
+	if s, ok := x.(String); ok {
+		return string(s)
+	}
+	return jqJSON(x)
+}
+
+func (n jqPipe) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.left.eval(env, in, func(x interface{}) error {
+		return n.right.eval(env, x, emit)
+	})
+}
+
+func (n jqComma) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	if err := n.left.eval(env, in, emit); err != nil {
+		return err
+	}
+	return n.right.eval(env, in, emit)
+}
+
+func (n jqAlt) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	var found bool
+	_, downstream := guard(n.left, env, in, func(x interface{}) error {
+		if !truthy(x) {
+			return nil
+		}
+		found = true
+		return emit(x)
+	})
+	if downstream != nil || found {
+		return downstream
+	}
+	return n.right.eval(env, in, emit)
+}
+
+func (n jqLogic) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.left.eval(env, in, func(a interface{}) error {
+		switch {
+		case n.op == "and" && !truthy(a):
+			return emit(Bool(false))
+		case n.op == "or" && truthy(a):
+			return emit(Bool(true))
+		}
+		return n.right.eval(env, in, func(b interface{}) error {
+			return emit(Bool(truthy(b)))
+		})
+	})
+}
+
+func (n jqBinary) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.right.eval(env, in, func(b interface{}) error {
+		return n.left.eval(env, in, func(a interface{}) error {
+			x, err := jqOperate(n.op, a, b)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		})
+	})
+}
+
+func (n jqNeg) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.x.eval(env, in, func(x interface{}) error {
+		num, ok := x.(Number)
+		if !ok {
+			return jqErrorf("%s cannot be negated", jqDescribe(x))
+		}
+		if strings.HasPrefix(string(num), "-") {
+			return emit(num[1:])
+		}
+		return emit("-" + num)
+	})
+}
+
+func (n jqIf) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.cond.eval(env, in, func(c interface{}) error {
+		switch {
+		case truthy(c):
+			return n.then.eval(env, in, emit)
+		case n.els != nil:
+			return n.els.eval(env, in, emit)
+		}
+		return emit(in)
+	})
+}
+
+func (n jqAs) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.term.eval(env, in, func(x interface{}) error {
+		return n.body.eval(&jqEnv{name: n.name, val: x, next: env}, in, emit)
+	})
+}
+
+func (n jqCall) eval(env *jqEnv, in interface{}, emit func(interface{}) error) error {
+	return n.fn(env, in, n.args, emit)
+}
+
+// maxJQDigits bounds the digits of computed numbers, so that 1e400 + 1
+// fails instead of spelling out 401 digits.
+const maxJQDigits = 100
+
+// jqNumber formats a computed number, failing if it has too many digits.
+func jqNumber(r *big.Rat) (interface{}, error) {
+	var n = string(ratNumber(r))
+	if len(n)-strings.Count(n, "-")-strings.Count(n, ".") > maxJQDigits {
+		return nil, jqErrorf("number has more than %d digits", maxJQDigits)
+	}
+	return Number(n), nil
+}
+
+func jqRat(x interface{}) (*big.Rat, error) {
+	n, ok := x.(Number)
+	if !ok {
+		return nil, jqErrorf("%s is not a number", jqDescribe(x))
+	}
+	r, err := n.Rat()
+	if err != nil {
+		return nil, jqErrorf("number %s out of range", string(n))
+	}
+	return r, nil
+}
+
+func jqRank(x interface{}) int {
+	switch v := x.(type) {
+	case Bool:
+		if v {
+			return 2
+		}
+		return 1
+	case Number:
+		return 3
+	case String:
+		return 4
+	case Array:
+		return 5
+	case Object:
+		return 6
+	}
+	return 0
+}
+
+// jqCompare orders values as jq does: null, false, true, numbers, strings,
+// arrays, then objects.
+func jqCompare(a, b interface{}) (int, error) {
+	if ra, rb := jqRank(a), jqRank(b); ra != rb {
+		return ra - rb, nil
+	}
+	switch a := a.(type) {
+	case Number:
+		x, err := jqRat(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := jqRat(b)
+		if err != nil {
+			return 0, err
+		}
+		return x.Cmp(y), nil
+	case String:
+		return strings.Compare(string(a), string(b.(String))), nil
+	case Array:
+		var b = b.(Array)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c, err := jqCompare(jqNorm(a[i]), jqNorm(b[i])); c != 0 || err != nil {
+				return c, err
+			}
+		}
+		return len(a) - len(b), nil
+	case Object:
+		var b = b.(Object)
+		var ka, kb = sortedKeys(a), sortedKeys(b)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c, nil
+			}
+		}
+		if len(ka) != len(kb) {
+			return len(ka) - len(kb), nil
+		}
+		for _, key := range ka {
+			if c, err := jqCompare(jqNorm(a[key]), jqNorm(b[key])); c != 0 || err != nil {
+				return c, err
+			}
+		}
+	}
+	return 0, nil
+}
+
+func jqOperate(op string, a, b interface{}) (interface{}, error) {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		c, err := jqCompare(a, b)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return Bool(c == 0), nil
+		case "!=":
+			return Bool(c != 0), nil
+		case "<":
+			return Bool(c < 0), nil
+		case "<=":
+			return Bool(c <= 0), nil
+		case ">":
+			return Bool(c > 0), nil
+		}
+		return Bool(c >= 0), nil
+	}
+
+	_, an := a.(Number)
+	_, bn := b.(Number)
+	if an && bn {
+		x, err := jqRat(a)
+		if err != nil {
+			return nil, err
+		}
+		y, err := jqRat(b)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "+":
+			return jqNumber(x.Add(x, y))
+		case "-":
+			return jqNumber(x.Sub(x, y))
+		case "*":
+			return jqNumber(x.Mul(x, y))
+		case "/":
+			if y.Sign() == 0 {
+				return nil, jqErrorf("%s and %s cannot be divided because the divisor is zero", jqDescribe(a), jqDescribe(b))
+			}
+			return jqNumber(x.Quo(x, y))
+		case "%":
+			var i = new(big.Int).Quo(x.Num(), x.Denom())
+			var j = new(big.Int).Quo(y.Num(), y.Denom())
+			if j.Sign() == 0 {
+				return nil, jqErrorf("%s and %s cannot be divided because the divisor is zero", jqDescribe(a), jqDescribe(b))
+			}
+			return Number(i.Rem(i, j).String()), nil
+		}
+	}
+
+	switch op {
+	case "+":
+		switch {
+		case a == nil:
+			return b, nil
+		case b == nil:
+			return a, nil
+		}
+		switch x := a.(type) {
+		case String:
+			if y, ok := b.(String); ok {
+				return x + y, nil
+			}
+		case Array:
+			if y, ok := b.(Array); ok {
+				return append(append(make(Array, 0, len(x)+len(y)), x...), y...), nil
+			}
+		case Object:
+			if y, ok := b.(Object); ok {
+				var obj = make(Object, len(x)+len(y))
+				for k, v := range x {
+					obj[k] = v
+				}
+				for k, v := range y {
+					obj[k] = v
+				}
+				return obj, nil
+			}
+		}
+		return nil, jqErrorf("%s and %s cannot be added", jqDescribe(a), jqDescribe(b))
+	case "-":
+		x, xok := a.(Array)
+		y, yok := b.(Array)
+		if xok && yok {
+			var arr = make(Array, 0, len(x))
+		outer:
+			for _, elem := range x {
+				for _, other := range y {
+					if c, err := jqCompare(jqNorm(elem), jqNorm(other)); err != nil {
+						return nil, err
+					} else if c == 0 {
+						continue outer
+					}
+				}
+				arr = append(arr, elem)
+			}
+			return arr, nil
+		}
+		return nil, jqErrorf("%s and %s cannot be subtracted", jqDescribe(a), jqDescribe(b))
+	case "*":
+		x, xok := a.(Object)
+		y, yok := b.(Object)
+		if xok && yok {
+			return jqMerge(x, y), nil
+		}
+		return nil, jqErrorf("%s and %s cannot be multiplied", jqDescribe(a), jqDescribe(b))
+	case "/":
+		x, xok := a.(String)
+		y, yok := b.(String)
+		if xok && yok {
+			return jqSplit(x, y), nil
+		}
+	}
+	return nil, jqErrorf("%s and %s cannot be divided", jqDescribe(a), jqDescribe(b))
+}
+
+// jqMerge merges y into a copy of x, recursing into Objects in both.
+func jqMerge(x, y Object) Object {
+	var obj = make(Object, len(x)+len(y))
+	for k, v := range x {
+		obj[k] = v
+	}
+	for k, v := range y {
+		xv, xok := jqNorm(obj[k]).(Object)
+		yv, yok := jqNorm(v).(Object)
+		if xok && yok {
+			obj[k] = jqMerge(xv, yv)
+		} else {
+			obj[k] = v
+		}
+	}
+	return obj
+}
+
+func jqSplit(s, sep String) Array {
+	var arr = make(Array, 0)
+	if s == "" {
+		return arr
+	}
+	for _, part := range strings.Split(string(s), string(sep)) {
+		arr = append(arr, String(part))
+	}
+	return arr
+}
+
+type jqBuiltin func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error
+
+// jqFunc adapts a function of the input alone.
+func jqFunc(fn func(in interface{}) (interface{}, error)) jqBuiltin {
+	return func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+		x, err := fn(in)
+		if err != nil {
+			return err
+		}
+		return emit(x)
+	}
+}
+
+// jqFunc1 adapts a function of the input and the value of its argument,
+// called for every output of the argument.
+func jqFunc1(fn func(in, arg interface{}) (interface{}, error)) jqBuiltin {
+	return func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+		return args[0].eval(env, in, func(arg interface{}) error {
+			x, err := fn(in, arg)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		})
+	}
+}
+
+// jqStrings adapts a function of a String input and String argument.
+func jqStrings(name string, fn func(s, arg string) interface{}) jqBuiltin {
+	return jqFunc1(func(in, arg interface{}) (interface{}, error) {
+		s, ok := in.(String)
+		a, aok := arg.(String)
+		if !ok || !aok {
+			return nil, jqErrorf("%s input and argument must be strings", name)
+		}
+		return fn(string(s), string(a)), nil
+	})
+}
+
+// jqValues returns the elements of an Array or the values of an Object.
+func jqValues(in interface{}) (Array, error) {
+	var arr = make(Array, 0)
+	err := jqEach(in, func(x interface{}) error {
+		arr = append(arr, x)
+		return nil
+	})
+	return arr, err
+}
+
+// jqCollect returns the outputs of node for in.
+func jqCollect(node jqNode, env *jqEnv, in interface{}) (Array, error) {
+	var arr = make(Array, 0)
+	err := node.eval(env, in, func(x interface{}) error {
+		arr = append(arr, x)
+		return nil
+	})
+	return arr, err
+}
+
+// jqSortBy sorts the Array in by the outputs of key for every element.
+func jqSortBy(env *jqEnv, in interface{}, key jqNode) (Array, []Array, error) {
+	arr, ok := in.(Array)
+	if !ok {
+		return nil, nil, jqErrorf("%s cannot be sorted, as it is not an array", jqDescribe(in))
+	}
+	var elems = make(Array, len(arr))
+	var keys = make([]Array, len(arr))
+	for i, elem := range arr {
+		elems[i] = jqNorm(elem)
+		var err error
+		if keys[i], err = jqCollect(key, env, elems[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	var order = make([]int, len(arr))
+	for i := range order {
+		order[i] = i
+	}
+	var err error
+	sort.SliceStable(order, func(i, j int) bool {
+		c, e := jqCompare(keys[order[i]], keys[order[j]])
+		if e != nil && err == nil {
+			err = e
+		}
+		return c < 0
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var sorted = make(Array, len(arr))
+	var sortedKeys = make([]Array, len(arr))
+	for i, idx := range order {
+		sorted[i], sortedKeys[i] = elems[idx], keys[idx]
+	}
+	return sorted, sortedKeys, nil
+}
+
+// jqGroups splits sorted elements into runs of equal keys.
+func jqGroups(sorted Array, keys []Array) ([]Array, error) {
+	var groups []Array
+	for i, elem := range sorted {
+		if i > 0 {
+			if c, err := jqCompare(keys[i-1], keys[i]); err != nil {
+				return nil, err
+			} else if c == 0 {
+				groups[len(groups)-1] = append(groups[len(groups)-1], elem)
+				continue
+			}
+		}
+		groups = append(groups, Array{elem})
+	}
+	return groups, nil
+}
+
+func jqEntries(in interface{}) (Array, error) {
+	obj, ok := in.(Object)
+	if !ok {
+		return nil, jqErrorf("%s has no keys", jqDescribe(in))
+	}
+	var arr = make(Array, 0, len(obj))
+	for _, key := range sortedKeys(obj) {
+		arr = append(arr, Object{"key": String(key), "value": jqNorm(obj[key])})
+	}
+	return arr, nil
+}
+
+func jqFromEntries(in interface{}) (interface{}, error) {
+	entries, err := jqValues(in)
+	if err != nil {
+		return nil, err
+	}
+	var obj = make(Object, len(entries))
+	for _, entry := range entries {
+		e, ok := entry.(Object)
+		if !ok {
+			return nil, jqErrorf("cannot use %s as an entry", jqDescribe(entry))
+		}
+		var key, val interface{}
+		for _, name := range []string{"key", "k", "name", "Name", "Key", "K"} {
+			if k := jqNorm(e[name]); truthy(k) {
+				key = k
+				break
+			}
+		}
+		for _, name := range []string{"value", "v", "Value", "V"} {
+			if _, ok := e[name]; ok {
+				val = jqNorm(e[name])
+				break
+			}
+		}
+		switch k := key.(type) {
+		case String:
+			obj[string(k)] = val
+		case Number, Bool:
+			obj[jqJSON(k)] = val
+		default:
+			return nil, jqErrorf("cannot use %s as an object key", jqDescribe(key))
+		}
+	}
+	return obj, nil
+}
+
+func jqContains(a, b interface{}) (bool, error) {
+	if jqType(a) != jqType(b) {
+		return false, jqErrorf("%s and %s cannot have their containment checked", jqDescribe(a), jqDescribe(b))
+	}
+	switch a := a.(type) {
+	case String:
+		return strings.Contains(string(a), string(b.(String))), nil
+	case Object:
+		for key, bv := range b.(Object) {
+			av, ok := a[key]
+			if !ok {
+				return false, nil
+			}
+			if c, err := jqContains(jqNorm(av), jqNorm(bv)); !c || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case Array:
+	next:
+		for _, bv := range b.(Array) {
+			for _, av := range a {
+				if c, err := jqContains(jqNorm(av), jqNorm(bv)); err == nil && c {
+					continue next
+				}
+			}
+			return false, nil
+		}
+		return true, nil
+	}
+	c, err := jqCompare(a, b)
+	return c == 0, err
+}
+
+// jqExtreme returns the element with the least (sign -1) or greatest (sign
+// 1) key, the last of equal greatest ones as jq does.
+func jqExtreme(env *jqEnv, in interface{}, key jqNode, sign int) (interface{}, error) {
+	sorted, _, err := jqSortBy(env, in, key)
+	if err != nil || len(sorted) == 0 {
+		return nil, err
+	}
+	if sign < 0 {
+		return sorted[0], nil
+	}
+	return sorted[len(sorted)-1], nil
+}
+
+var jqBuiltins map[string]jqBuiltin
+
+func init() {
+	var identity jqNode = jqIdentity{}
+	jqBuiltins = map[string]jqBuiltin{
+		"empty/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return nil
+		},
+		"not/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return Bool(!truthy(in)), nil
+		}),
+		"length/0": jqFunc(func(in interface{}) (interface{}, error) {
+			switch v := in.(type) {
+			case nil:
+				return Number("0"), nil
+			case Number:
+				return Number(strings.TrimPrefix(string(v), "-")), nil
+			case String:
+				return value(utf8.RuneCountInString(string(v))).value, nil
+			case Array:
+				return value(len(v)).value, nil
+			case Object:
+				return value(len(v)).value, nil
+			}
+			return nil, jqErrorf("%s has no length", jqDescribe(in))
+		}),
+		"keys/0": jqFunc(func(in interface{}) (interface{}, error) {
+			switch v := in.(type) {
+			case Object:
+				var arr = make(Array, 0, len(v))
+				for _, key := range sortedKeys(v) {
+					arr = append(arr, String(key))
+				}
+				return arr, nil
+			case Array:
+				var arr = make(Array, len(v))
+				for i := range v {
+					arr[i] = value(i).value
+				}
+				return arr, nil
+			}
+			return nil, jqErrorf("%s has no keys", jqDescribe(in))
+		}),
+		"has/1": jqFunc1(func(in, arg interface{}) (interface{}, error) {
+			switch v := in.(type) {
+			case Object:
+				if k, ok := arg.(String); ok {
+					_, exists := v[string(k)]
+					return Bool(exists), nil
+				}
+			case Array:
+				if i, ok := jqInt(arg); ok {
+					return Bool(i >= 0 && i < len(v)), nil
+				}
+			}
+			return nil, jqErrorf("cannot check whether %s has a %s key", jqType(in), jqType(arg))
+		}),
+		"values/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			if in == nil {
+				return nil
+			}
+			return emit(in)
+		},
+		"to_entries/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return jqEntries(in)
+		}),
+		"from_entries/0": jqFunc(jqFromEntries),
+		"with_entries/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			entries, err := jqEntries(in)
+			if err != nil {
+				return err
+			}
+			var mapped = make(Array, 0, len(entries))
+			for _, entry := range entries {
+				out, err := jqCollect(args[0], env, entry)
+				if err != nil {
+					return err
+				}
+				mapped = append(mapped, out...)
+			}
+			obj, err := jqFromEntries(mapped)
+			if err != nil {
+				return err
+			}
+			return emit(obj)
+		},
+		"select/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return args[0].eval(env, in, func(c interface{}) error {
+				if truthy(c) {
+					return emit(in)
+				}
+				return nil
+			})
+		},
+		"map/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			elems, err := jqValues(in)
+			if err != nil {
+				return err
+			}
+			var arr = make(Array, 0, len(elems))
+			for _, elem := range elems {
+				out, err := jqCollect(args[0], env, elem)
+				if err != nil {
+					return err
+				}
+				arr = append(arr, out...)
+			}
+			return emit(arr)
+		},
+		"map_values/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			switch v := in.(type) {
+			case Array:
+				var arr = make(Array, 0, len(v))
+				for _, elem := range v {
+					out, err := jqCollect(args[0], env, jqNorm(elem))
+					if err != nil {
+						return err
+					}
+					if len(out) > 0 {
+						arr = append(arr, out[0])
+					}
+				}
+				return emit(arr)
+			case Object:
+				var obj = make(Object, len(v))
+				for key, elem := range v {
+					out, err := jqCollect(args[0], env, jqNorm(elem))
+					if err != nil {
+						return err
+					}
+					if len(out) > 0 {
+						obj[key] = out[0]
+					}
+				}
+				return emit(obj)
+			}
+			return jqErrorf("cannot iterate over %s", jqDescribe(in))
+		},
+		"add/0": jqFunc(func(in interface{}) (interface{}, error) {
+			elems, err := jqValues(in)
+			if err != nil {
+				return nil, err
+			}
+			var sum interface{}
+			for _, elem := range elems {
+				if sum, err = jqOperate("+", sum, elem); err != nil {
+					return nil, err
+				}
+			}
+			return sum, nil
+		}),
+		"any/0": jqFunc(func(in interface{}) (interface{}, error) {
+			elems, err := jqValues(in)
+			for _, elem := range elems {
+				if truthy(elem) {
+					return Bool(true), nil
+				}
+			}
+			return Bool(false), err
+		}),
+		"all/0": jqFunc(func(in interface{}) (interface{}, error) {
+			elems, err := jqValues(in)
+			for _, elem := range elems {
+				if !truthy(elem) {
+					return Bool(false), nil
+				}
+			}
+			return Bool(true), err
+		}),
+		"type/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return String(jqType(in)), nil
+		}),
+		"tostring/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return String(jqToString(in)), nil
+		}),
+		"tonumber/0": jqFunc(func(in interface{}) (interface{}, error) {
+			switch v := in.(type) {
+			case Number:
+				return v, nil
+			case String:
+				if _, err := Number(v).Rat(); err == nil {
+					return Number(v), nil
+				}
+			}
+			return nil, jqErrorf("cannot parse %s as a number", jqDescribe(in))
+		}),
+		"tojson/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return String(jqJSON(in)), nil
+		}),
+		"fromjson/0": jqFunc(func(in interface{}) (interface{}, error) {
+			s, ok := in.(String)
+			if !ok {
+				return nil, jqErrorf("%s cannot be parsed as JSON", jqDescribe(in))
+			}
+			val, err := Unmarshal([]byte(s))
+			if err != nil {
+				return nil, jqErrorf("%s cannot be parsed as JSON", jqDescribe(in))
+			}
+			return normalize(val.value)
+		}),
+		"sort/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			sorted, _, err := jqSortBy(env, in, identity)
+			if err != nil {
+				return err
+			}
+			return emit(sorted)
+		},
+		"sort_by/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			sorted, _, err := jqSortBy(env, in, args[0])
+			if err != nil {
+				return err
+			}
+			return emit(sorted)
+		},
+		"group_by/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			sorted, keys, err := jqSortBy(env, in, args[0])
+			if err != nil {
+				return err
+			}
+			groups, err := jqGroups(sorted, keys)
+			if err != nil {
+				return err
+			}
+			var arr = make(Array, len(groups))
+			for i, group := range groups {
+				arr[i] = group
+			}
+			return emit(arr)
+		},
+		"unique/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return jqBuiltins["unique_by/1"](env, in, []jqNode{identity}, emit)
+		},
+		"unique_by/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			sorted, keys, err := jqSortBy(env, in, args[0])
+			if err != nil {
+				return err
+			}
+			groups, err := jqGroups(sorted, keys)
+			if err != nil {
+				return err
+			}
+			var arr = make(Array, len(groups))
+			for i, group := range groups {
+				arr[i] = group[0]
+			}
+			return emit(arr)
+		},
+		"min/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			x, err := jqExtreme(env, in, identity, -1)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		},
+		"max/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			x, err := jqExtreme(env, in, identity, 1)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		},
+		"min_by/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			x, err := jqExtreme(env, in, args[0], -1)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		},
+		"max_by/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			x, err := jqExtreme(env, in, args[0], 1)
+			if err != nil {
+				return err
+			}
+			return emit(x)
+		},
+		"reverse/0": jqFunc(func(in interface{}) (interface{}, error) {
+			switch v := in.(type) {
+			case nil:
+				return Array{}, nil
+			case String:
+				var r = []rune(string(v))
+				for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+					r[i], r[j] = r[j], r[i]
+				}
+				return String(r), nil
+			case Array:
+				var arr = make(Array, len(v))
+				for i, elem := range v {
+					arr[len(v)-1-i] = elem
+				}
+				return arr, nil
+			}
+			return nil, jqErrorf("cannot reverse %s", jqDescribe(in))
+		}),
+		"first/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return jqIndexValue(in, Number("0"))
+		}),
+		"last/0": jqFunc(func(in interface{}) (interface{}, error) {
+			return jqIndexValue(in, Number("-1"))
+		}),
+		"first/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			var stop = &jqStop{}
+			var first interface{}
+			var found bool
+			err := args[0].eval(env, in, func(x interface{}) error {
+				first, found = x, true
+				return stop
+			})
+			if err != nil && err != stop {
+				return err
+			}
+			if !found {
+				return nil
+			}
+			return emit(first)
+		},
+		"last/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			var last interface{}
+			var found bool
+			err := args[0].eval(env, in, func(x interface{}) error {
+				last, found = x, true
+				return nil
+			})
+			if err != nil || !found {
+				return err
+			}
+			return emit(last)
+		},
+		"limit/2": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return args[0].eval(env, in, func(n interface{}) error {
+				limit, ok := jqInt(n)
+				if !ok {
+					return jqErrorf("limit must be a number")
+				}
+				if limit <= 0 {
+					return nil
+				}
+				var stop = &jqStop{}
+				var count int
+				err := args[1].eval(env, in, func(x interface{}) error {
+					if err := emit(x); err != nil {
+						return err
+					}
+					if count++; count == limit {
+						return stop
+					}
+					return nil
+				})
+				if err == stop {
+					return nil
+				}
+				return err
+			})
+		},
+		"range/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return jqBuiltins["range/2"](env, in, []jqNode{jqLiteral{Number("0")}, args[0]}, emit)
+		},
+		"range/2": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return args[0].eval(env, in, func(from interface{}) error {
+				return args[1].eval(env, in, func(upto interface{}) error {
+					x, err := jqRat(from)
+					if err != nil {
+						return err
+					}
+					end, err := jqRat(upto)
+					if err != nil {
+						return err
+					}
+					var one = big.NewRat(1, 1)
+					for ; x.Cmp(end) < 0; x.Add(x, one) {
+						n, err := jqNumber(x)
+						if err != nil {
+							return err
+						}
+						if err = emit(n); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			})
+		},
+		"floor/0": jqFunc(func(in interface{}) (interface{}, error) {
+			r, err := jqRat(in)
+			if err != nil {
+				return nil, err
+			}
+			var q = new(big.Int).Div(r.Num(), r.Denom())
+			return Number(q.String()), nil
+		}),
+		"join/1": jqFunc1(func(in, sep interface{}) (interface{}, error) {
+			elems, err := jqValues(in)
+			if err != nil {
+				return nil, err
+			}
+			s, ok := sep.(String)
+			if !ok {
+				return nil, jqErrorf("join separator must be a string")
+			}
+			var parts = make([]string, len(elems))
+			for i, elem := range elems {
+				switch v := elem.(type) {
+				case nil:
+				case String:
+					parts[i] = string(v)
+				case Number, Bool:
+					parts[i] = jqJSON(v)
+				default:
+					return nil, jqErrorf("cannot join with %s", jqDescribe(elem))
+				}
+			}
+			return String(strings.Join(parts, string(s))), nil
+		}),
+		"split/1": jqStrings("split", func(s, sep string) interface{} {
+			return jqSplit(String(s), String(sep))
+		}),
+		"ascii_downcase/0": jqFunc(func(in interface{}) (interface{}, error) {
+			s, ok := in.(String)
+			if !ok {
+				return nil, jqErrorf("%s cannot be lowercased", jqDescribe(in))
+			}
+			return String(strings.Map(func(r rune) rune {
+				if r >= 'A' && r <= 'Z' {
+					return r + 'a' - 'A'
+				}
+				return r
+			}, string(s))), nil
+		}),
+		"ascii_upcase/0": jqFunc(func(in interface{}) (interface{}, error) {
+			s, ok := in.(String)
+			if !ok {
+				return nil, jqErrorf("%s cannot be uppercased", jqDescribe(in))
+			}
+			return String(strings.Map(func(r rune) rune {
+				if r >= 'a' && r <= 'z' {
+					return r - 'a' + 'A'
+				}
+				return r
+			}, string(s))), nil
+		}),
+		"startswith/1": jqStrings("startswith", func(s, prefix string) interface{} {
+			return Bool(strings.HasPrefix(s, prefix))
+		}),
+		"endswith/1": jqStrings("endswith", func(s, suffix string) interface{} {
+			return Bool(strings.HasSuffix(s, suffix))
+		}),
+		"ltrimstr/1": jqFunc1(func(in, arg interface{}) (interface{}, error) {
+			s, ok := in.(String)
+			prefix, pok := arg.(String)
+			if ok && pok {
+				return String(strings.TrimPrefix(string(s), string(prefix))), nil
+			}
+			return in, nil
+		}),
+		"rtrimstr/1": jqFunc1(func(in, arg interface{}) (interface{}, error) {
+			s, ok := in.(String)
+			suffix, sok := arg.(String)
+			if ok && sok {
+				return String(strings.TrimSuffix(string(s), string(suffix))), nil
+			}
+			return in, nil
+		}),
+		"test/1": jqFunc1(func(in, arg interface{}) (interface{}, error) {
+			s, ok := in.(String)
+			pattern, pok := arg.(String)
+			if !ok || !pok {
+				return nil, jqErrorf("test input and pattern must be strings")
+			}
+			re, err := regexp.Compile(string(pattern))
+			if err != nil {
+				return nil, jqErrorf("%v", err)
+			}
+			return Bool(re.MatchString(string(s))), nil
+		}),
+		"contains/1": jqFunc1(func(in, arg interface{}) (interface{}, error) {
+			c, err := jqContains(in, arg)
+			return Bool(c), err
+		}),
+		"flatten/0": jqFunc(func(in interface{}) (interface{}, error) {
+			arr, ok := in.(Array)
+			if !ok {
+				return nil, jqErrorf("cannot flatten %s", jqDescribe(in))
+			}
+			return flattenArray(make(Array, 0), arr, -1), nil
+		}),
+		"flatten/1": jqFunc1(func(in, depth interface{}) (interface{}, error) {
+			arr, ok := in.(Array)
+			d, dok := jqInt(depth)
+			if !ok || !dok || d < 0 {
+				return nil, jqErrorf("flatten needs an array and a depth that is not negative")
+			}
+			return flattenArray(make(Array, 0), arr, d), nil
+		}),
+		"recurse/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return jqRecurse{}.eval(env, in, emit)
+		},
+		"error/0": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return &jqError{in}
+		},
+		"error/1": func(env *jqEnv, in interface{}, args []jqNode, emit func(interface{}) error) error {
+			return args[0].eval(env, in, func(msg interface{}) error {
+				return &jqError{msg}
+			})
+		},
+	}
+	jqBuiltins["keys_unsorted/0"] = jqBuiltins["keys/0"]
+}
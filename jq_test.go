@@ -0,0 +1,136 @@
+package jsons
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+const jqDoc = `{
+	"name": "svc",
+	"replicas": 3,
+	"price": 0.10,
+	"tags": ["a", "b"],
+	"users": [
+		{"name": "ann", "age": 31, "admin": true},
+		{"name": "bob", "age": 25},
+		{"name": "cat", "age": 40, "admin": false}
+	],
+	"labels": {"env": "prod", "team": "core"}
+}`
+
+type jqCase struct {
+	filter string
+	want   string
+}
+
+// testJQ runs every filter on jqDoc and compares its outputs, separated by
+// spaces, as compact JSON.
+func testJQ(t *testing.T, cases []jqCase) {
+	val, err := Unmarshal([]byte(jqDoc))
+	assert.NoError(t, err)
+	for _, c := range cases {
+		q, err := CompileJQ(c.filter)
+		assert.NoError(t, err, c.filter)
+		out, err := q.Run(val)
+		assert.NoError(t, err, c.filter)
+		var got []string
+		for _, v := range out {
+			got = append(got, v.JSONString())
+		}
+		assert.Equal(t, strings.Join(got, " "), c.want, c.filter)
+	}
+}
+
+func TestJQ_Paths(t *testing.T) {
+	testJQ(t, []jqCase{
+		{`.`, `{"labels":{"env":"prod","team":"core"},"name":"svc","price":0.10,"replicas":3,"tags":["a","b"],"users":[{"admin":true,"age":31,"name":"ann"},{"age":25,"name":"bob"},{"admin":false,"age":40,"name":"cat"}]}`},
+		{`.name`, `"svc"`},
+		{`.price`, `0.10`},
+		{`.users[0].name`, `"ann"`},
+		{`.users[-1].age`, `40`},
+		{`.users[].name`, `"ann" "bob" "cat"`},
+		{`.tags[1:]`, `["b"]`},
+		{`.name[1:]`, `"vc"`},
+		{`.missing.deeper`, `null`},
+		{`."name"`, `"svc"`},
+		{`.["labels"].env`, `"prod"`},
+		{`.labels[]`, `"prod" "core"`},
+		{`.name.x?`, ``},
+		{`[.labels | ..] | length`, `3`},
+		{`.a, .b | values`, ``},
+		{`empty, 1 # comment`, `1`},
+	})
+}
+
+func TestJQ_Operators(t *testing.T) {
+	testJQ(t, []jqCase{
+		{`.replicas * 2 + 1, .price + 0.2, 1 / 3, 7 % 3`, `7 0.3 0.3333333333333333333333333333333333 1`},
+		{`-.replicas`, `-3`},
+		{`.tags + ["c"], .labels + {"x": 1} | length`, `3 3`},
+		{`{"a": {"b": 1}} * {"a": {"c": 2}}`, `{"a":{"b":1,"c":2}}`},
+		{`[1, 2, 3, 2] - [2]`, `[1,3]`},
+		{`"a,b" / ","`, `["a","b"]`},
+		{`1 == 1.0, "a" < "b", [1] < [1, 0], null < false, {} > []`, `true true true true true`},
+		{`(1, 2) + (10, 20)`, `11 12 21 22`},
+		{`12345678901234567890123 + 1`, `12345678901234567890124`},
+		{`1e98 * 10 - 1 | tostring | length`, `99`},
+		{`.users | map(.admin // false)`, `[true,false,false]`},
+	})
+}
+
+func TestJQ_Language(t *testing.T) {
+	testJQ(t, []jqCase{
+		{`{n: .name, count: (.users | length), tags}`, `{"count":3,"n":"svc","tags":["a","b"]}`},
+		{`{(.labels.env): .replicas}`, `{"prod":3}`},
+		{`"\(.name) has \(.replicas) replicas at \(.price)"`, `"svc has 3 replicas at 0.10"`},
+		{`.users[] | if .age > 35 then "old" elif .admin then "admin" else "other" end`, `"admin" "other" "old"`},
+		{`.replicas as $n | [range($n)]`, `[0,1,2]`},
+		{`try error("boom") catch .`, `"boom"`},
+		{`try (.name | length | .x) catch .`, `"cannot index number with \"x\""`},
+	})
+}
+
+func TestJQ_Builtins(t *testing.T) {
+	testJQ(t, []jqCase{
+		{`[.users[] | select(.age > 30) | .name]`, `["ann","cat"]`},
+		{`.users | map(.age) | add`, `96`},
+		{`.labels | to_entries | map("\(.key)=\(.value)") | join(",")`, `"env=prod,team=core"`},
+		{`.labels | with_entries(select(.key == "env"))`, `{"env":"prod"}`},
+		{`.labels | keys`, `["env","team"]`},
+		{`.users | sort_by(.age) | map(.name)`, `["bob","ann","cat"]`},
+		{`.users | group_by(.admin) | map(length)`, `[1,1,1]`},
+		{`.users | max_by(.age) | .name`, `"cat"`},
+		{`[3, 1, 2, 1] | sort, unique, min, max, reverse`, `[1,1,2,3] [1,2,3] 1 3 [1,2,1,3]`},
+		{`([limit(2; .users[])] | length), first(.users[]).name, last(.users[]).name`, `2 "ann" "cat"`},
+		{`.tags | has(0), contains(["a"])`, `true true`},
+		{`.name | test("^s"), startswith("sv"), ascii_upcase, ltrimstr("s")`, `true true "SVC" "vc"`},
+		{`"42" | tonumber, (42 | tostring), ([1] | tojson), ("[2]" | fromjson)`, `42 "42" "[1]" [2]`},
+		{`[[1, [2]], 3] | flatten, flatten(1)`, `[1,2,3] [1,[2],3]`},
+		{`.users | any, all`, `true true`},
+		{`[.users[].admin] | map(type)`, `["boolean","null","boolean"]`},
+		{`3.7 | floor, (-3.2 | floor)`, `3 -4`},
+	})
+}
+
+func TestJQ_Errors(t *testing.T) {
+	for _, filter := range []string{`.[`, `.a |`, `{a: }`, `"abc`, `foo(1)`, `if . then 1`, `.a as x | .`, `1 +`, `)`, `.a |= 1`, `numbers`, `$x`, `$ENV.HOME`, `{$x}`, `(1 as $x | $x) | $x`, `.a as $x | $y`} {
+		_, err := CompileJQ(filter)
+		assert.Error(t, err, filter)
+	}
+
+	val, _ := Unmarshal([]byte(jqDoc))
+	for _, filter := range []string{`.name.x`, `.users[] | .name[0]`, `.labels | keys | .[0] + 1`, `1 / 0`, `error`, `.labels[] | length + "x"`, `1e400 + 1`, `1e-400 * 1`, `1e60 * 1e60`} {
+		q, err := CompileJQ(filter)
+		assert.NoError(t, err, filter)
+		_, err = q.Run(val)
+		assert.Error(t, err, filter)
+	}
+
+	_, err := CompileJQ(`.a as $y | $x`)
+	assert.EqualError(t, err, "jsons: jq: offset 11: $x is not defined")
+
+	_, err = CompileJQ(strings.Repeat("(", 2000) + "." + strings.Repeat(")", 2000))
+	assert.Error(t, err)
+}
@@ -0,0 +1,490 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UnmarshalJSONC parses JSON with comments: standard JSON plus // and /* */
+// comments and trailing commas.
+func UnmarshalJSONC(data []byte) (Value, error) {
+	return unmarshalRelaxed(data, false, NonFiniteError)
+}
+
+// NonFinite selects what JSON5's Infinity, -Infinity and NaN become, as JSON
+// has no numbers for them.
+type NonFinite int
+
+const (
+	// NonFiniteError rejects them.
+	NonFiniteError NonFinite = iota
+	// NonFiniteNull reads them as null.
+	NonFiniteNull
+	// NonFiniteString reads them as the Strings "Infinity", "-Infinity" and
+	// "NaN".
+	NonFiniteString
+)
+
+// JSON5Options configures UnmarshalJSON5WithOptions.
+type JSON5Options struct {
+	NonFinite NonFinite
+}
+
+// UnmarshalJSON5 parses JSON5 (https://json5.org): JSONC plus single-quoted
+// strings, unquoted keys, hexadecimal numbers, Infinity and NaN. Infinity
+// and NaN are rejected; see UnmarshalJSON5WithOptions.
+func UnmarshalJSON5(data []byte) (Value, error) {
+	return UnmarshalJSON5WithOptions(data, JSON5Options{})
+}
+
+// UnmarshalJSON5WithOptions is UnmarshalJSON5 with options.
+func UnmarshalJSON5WithOptions(data []byte, opts JSON5Options) (Value, error) {
+	return unmarshalRelaxed(data, true, opts.NonFinite)
+}
+
+func unmarshalRelaxed(data []byte, json5 bool, nonFinite NonFinite) (val Value, err error) {
+	var p = relaxedParser{data: data, json5: json5, nonFinite: nonFinite}
+	if err = p.space(); err != nil {
+		return
+	}
+	if val.value, err = p.value(); err != nil {
+		return
+	}
+	if err = p.space(); err != nil {
+		return
+	}
+	if p.pos < len(data) {
+		return Value{}, p.error(ErrTrailingData)
+	}
+	return
+}
+
+// relaxedParser parses JSONC and JSON5 into the tree Unmarshal produces.
+type relaxedParser struct {
+	data      []byte
+	pos       int
+	json5     bool
+	nonFinite NonFinite
+	path      []interface{}
+}
+
+func (p *relaxedParser) error(err error) error {
+	return &UnmarshalError{Offset: int64(p.pos), Path: JSONPath(p.path...), Err: err}
+}
+
+func (p *relaxedParser) unexpected() error {
+	if p.pos >= len(p.data) {
+		return p.error(fmt.Errorf("unexpected end of input"))
+	}
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	return p.error(fmt.Errorf("invalid character %q", r))
+}
+
+func (p *relaxedParser) isSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\r':
+		return true
+	case '\v', '\f', '\u00a0', '\ufeff', '\u2028', '\u2029':
+		return p.json5
+	}
+	return p.json5 && unicode.Is(unicode.Zs, r)
+}
+
+// space skips whitespace and comments.
+func (p *relaxedParser) space() error {
+	for p.pos < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		switch {
+		case p.isSpace(r):
+			p.pos += size
+		case r == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		case r == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '*':
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				return p.error(fmt.Errorf("unterminated comment"))
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *relaxedParser) value() (interface{}, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.unexpected()
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"' || c == '\'' && p.json5:
+		return p.string()
+	case c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9' || c == 'I' || c == 'N':
+		return p.number()
+	}
+	for _, lit := range []struct {
+		name  string
+		value interface{}
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if bytes.HasPrefix(p.data[p.pos:], []byte(lit.name)) {
+			p.pos += len(lit.name)
+			return lit.value, nil
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func (p *relaxedParser) object() (interface{}, error) {
+	var obj = make(map[string]interface{})
+	p.pos++
+	for {
+		if err := p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			p.pos++
+			return obj, nil
+		}
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		if err = p.space(); err != nil {
+			return nil, err
+		}
+		p.path = append(p.path, key)
+		if obj[key], err = p.value(); err != nil {
+			return nil, err
+		}
+		p.path = p.path[:len(p.path)-1]
+		if err = p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			p.pos++
+			return obj, nil
+		}
+		return nil, p.unexpected()
+	}
+}
+
+func (p *relaxedParser) array() (interface{}, error) {
+	var arr = make([]interface{}, 0)
+	p.pos++
+	for {
+		if err := p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return arr, nil
+		}
+		p.path = append(p.path, len(arr))
+		elem, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		p.path = p.path[:len(p.path)-1]
+		arr = append(arr, elem)
+		if err = p.space(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return arr, nil
+		}
+		return nil, p.unexpected()
+	}
+}
+
+func (p *relaxedParser) key() (string, error) {
+	if p.pos < len(p.data) && (p.data[p.pos] == '"' || p.data[p.pos] == '\'' && p.json5) {
+		return p.string()
+	}
+	if !p.json5 {
+		return "", p.unexpected()
+	}
+	var key strings.Builder
+	for p.pos < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		if r == '\\' {
+			if p.pos+1 >= len(p.data) || p.data[p.pos+1] != 'u' {
+				return "", p.unexpected()
+			}
+			p.pos += 2
+			var err error
+			if r, err = p.unicodeEscape(); err != nil {
+				return "", err
+			}
+			size = 0
+		}
+		if !isIdentifierRune(r, key.Len() == 0) {
+			if size == 0 {
+				return "", p.error(fmt.Errorf("invalid identifier character %q", r))
+			}
+			break
+		}
+		key.WriteRune(r)
+		p.pos += size
+	}
+	if key.Len() == 0 {
+		return "", p.unexpected()
+	}
+	return key.String(), nil
+}
+
+func isIdentifierRune(r rune, first bool) bool {
+	switch {
+	case r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r):
+		return true
+	case first:
+		return false
+	case r == '\u200c' || r == '\u200d':
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc)
+}
+
+func (p *relaxedParser) string() (string, error) {
+	var quote = p.data[p.pos]
+	var str strings.Builder
+	p.pos++
+	for p.pos < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		switch {
+		case r == rune(quote):
+			p.pos++
+			return str.String(), nil
+		case r == '\\':
+			p.pos++
+			if err := p.escape(&str); err != nil {
+				return "", err
+			}
+			continue
+		case r == '\n' || r == '\r' || r < 0x20 && !p.json5:
+			return "", p.unexpected()
+		case r == utf8.RuneError && size == 1:
+			str.WriteRune(utf8.RuneError)
+		default:
+			str.WriteString(string(p.data[p.pos : p.pos+size]))
+		}
+		p.pos += size
+	}
+	return "", p.error(fmt.Errorf("unterminated string"))
+}
+
+func (p *relaxedParser) escape(str *strings.Builder) error {
+	if p.pos >= len(p.data) {
+		return p.unexpected()
+	}
+	var c = p.data[p.pos]
+	var simple = map[byte]string{'"': `"`, '\\': `\`, '/': `/`, 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t"}
+	if s, ok := simple[c]; ok {
+		str.WriteString(s)
+		p.pos++
+		return nil
+	}
+	if c == 'u' {
+		p.pos++
+		r, err := p.unicodeEscape()
+		if err != nil {
+			return err
+		}
+		str.WriteRune(r)
+		return nil
+	}
+	if !p.json5 {
+		return p.unexpected()
+	}
+	switch c {
+	case '\'':
+		str.WriteByte('\'')
+	case 'v':
+		str.WriteByte('\v')
+	case '0':
+		if p.pos+1 < len(p.data) && p.data[p.pos+1] >= '0' && p.data[p.pos+1] <= '9' {
+			return p.unexpected()
+		}
+		str.WriteByte(0)
+	case 'x':
+		if p.pos+3 > len(p.data) {
+			return p.unexpected()
+		}
+		b, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8)
+		if err != nil {
+			return p.unexpected()
+		}
+		str.WriteRune(rune(b))
+		p.pos += 2
+	case '\r':
+		if p.pos+1 < len(p.data) && p.data[p.pos+1] == '\n' {
+			p.pos++
+		}
+	case '\n':
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return p.unexpected()
+	default:
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		if r != '\u2028' && r != '\u2029' {
+			str.WriteRune(r)
+		}
+		p.pos += size
+		return nil
+	}
+	p.pos++
+	return nil
+}
+
+// unicodeEscape reads the hex digits of a \u escape, joining surrogate pairs.
+func (p *relaxedParser) unicodeEscape() (rune, error) {
+	hex4 := func() (rune, error) {
+		if p.pos+4 > len(p.data) {
+			return 0, p.unexpected()
+		}
+		u, err := strconv.ParseUint(string(p.data[p.pos:p.pos+4]), 16, 16)
+		if err != nil {
+			return 0, p.error(fmt.Errorf("invalid unicode escape"))
+		}
+		p.pos += 4
+		return rune(u), nil
+	}
+	r, err := hex4()
+	if err != nil || !utf16.IsSurrogate(r) {
+		return r, err
+	}
+	if p.pos+2 <= len(p.data) && p.data[p.pos] == '\\' && p.data[p.pos+1] == 'u' {
+		var pos = p.pos
+		p.pos += 2
+		r2, err := hex4()
+		if err != nil {
+			return 0, err
+		}
+		if r := utf16.DecodeRune(r, r2); r != unicode.ReplacementChar {
+			return r, nil
+		}
+		p.pos = pos
+	}
+	return unicode.ReplacementChar, nil
+}
+
+func (p *relaxedParser) nonFiniteNumber(start int, name string) (interface{}, error) {
+	switch p.nonFinite {
+	case NonFiniteNull:
+		p.pos += len(strings.TrimPrefix(name, "-"))
+		return nil, nil
+	case NonFiniteString:
+		p.pos += len(strings.TrimPrefix(name, "-"))
+		return name, nil
+	}
+	p.pos = start
+	return nil, p.error(fmt.Errorf("%s is not a valid JSON number", name))
+}
+
+func (p *relaxedParser) number() (interface{}, error) {
+	var start = p.pos
+	var sign string
+	if c := p.data[p.pos]; c == '-' || c == '+' {
+		if !p.json5 && c == '+' {
+			return nil, p.unexpected()
+		}
+		if c == '-' {
+			sign = "-"
+		}
+		p.pos++
+	}
+	rest := p.data[p.pos:]
+	switch {
+	case p.json5 && bytes.HasPrefix(rest, []byte("Infinity")):
+		return p.nonFiniteNumber(start, sign+"Infinity")
+	case p.json5 && bytes.HasPrefix(rest, []byte("NaN")):
+		return p.nonFiniteNumber(start, "NaN")
+	case p.json5 && (bytes.HasPrefix(rest, []byte("0x")) || bytes.HasPrefix(rest, []byte("0X"))):
+		p.pos += 2
+		var digits = p.pos
+		for p.pos < len(p.data) && strings.IndexByte("0123456789abcdefABCDEF", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, ok := new(big.Int).SetString(string(p.data[digits:p.pos]), 16)
+		if !ok {
+			return nil, p.unexpected()
+		}
+		if sign == "-" {
+			n.Neg(n)
+		}
+		return json.Number(n.String()), nil
+	}
+
+	digits := func() int {
+		var from = p.pos
+		for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+			p.pos++
+		}
+		return p.pos - from
+	}
+	intDigits := digits()
+	if intDigits == 0 && !p.json5 {
+		return nil, p.unexpected()
+	}
+	if intDigits > 1 && p.data[p.pos-intDigits] == '0' {
+		p.pos -= intDigits - 1
+		return nil, p.unexpected()
+	}
+	var fracDigits = -1
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		if fracDigits = digits(); fracDigits == 0 && (!p.json5 || intDigits == 0) {
+			return nil, p.unexpected()
+		}
+	} else if intDigits == 0 {
+		return nil, p.unexpected()
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return nil, p.unexpected()
+		}
+	}
+	if p.pos == start {
+		return nil, p.unexpected()
+	}
+	// JSON5's +1, .5 and 5. become 1, 0.5 and 5.0 so the Number is JSON.
+	var num = strings.TrimPrefix(string(p.data[start:p.pos]), "+")
+	switch {
+	case intDigits == 0:
+		num = strings.Replace(num, ".", "0.", 1)
+	case fracDigits == 0:
+		num = strings.Replace(num, ".", ".0", 1)
+	}
+	return json.Number(num), nil
+}
@@ -0,0 +1,104 @@
+package jsons
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestUnmarshalJSONC(t *testing.T) {
+	val, err := UnmarshalJSONC([]byte(`
+		// server config
+		{
+			"host": "localhost", /* inline */
+			"ports": [80, 443,], // trailing comma
+			"tls": {"enabled": true, "ratio": 1.50,},
+		}
+	`))
+	assert.NoError(t, err)
+	expected, _ := Unmarshal([]byte(`{"host": "localhost", "ports": [80, 443], "tls": {"enabled": true, "ratio": 1.50}}`))
+	assert.Equal(t, val, expected)
+
+	for _, data := range []string{
+		`{'a': 1}`, `{a: 1}`, `[0x10]`, `[+1]`, `[.5]`, `[Infinity]`, `["\x41"]`,
+		`[1,,]`, `{"a" 1}`, `/* open`, `[1] 2`, `[01]`,
+	} {
+		_, err = UnmarshalJSONC([]byte(data))
+		assert.Error(t, err, data)
+	}
+
+	_, err = UnmarshalJSONC([]byte(`{"a": [1, 2 3]}`))
+	var unmarshalErr *UnmarshalError
+	assert.True(t, errors.As(err, &unmarshalErr))
+	assert.Equal(t, unmarshalErr.Offset, int64(12))
+	assert.Equal(t, unmarshalErr.Path, "$.a")
+}
+
+func TestUnmarshalJSON5(t *testing.T) {
+	val, err := UnmarshalJSON5([]byte(`
+		// https://json5.org example
+		{
+			unquoted: 'and you can quote me on that',
+			singleQuotes: 'I can use "double quotes" here',
+			lineBreaks: "Look, Mom! \
+No \\n's!",
+			hexadecimal: 0xdecaf,
+			leadingDecimalPoint: .8675309, andTrailing: 8675309.,
+			positiveSign: +1,
+			trailingComma: 'in objects', andIn: ['arrays',],
+			"backwardsCompatible": "with JSON",
+			$_ünicodeA: '\x41é\0',
+		}
+	`))
+	assert.NoError(t, err)
+	assert.Equal(t, val.String("unquoted"), "and you can quote me on that")
+	assert.Equal(t, val.String("singleQuotes"), `I can use "double quotes" here`)
+	assert.Equal(t, val.String("lineBreaks"), `Look, Mom! No \n's!`)
+	assert.Equal(t, val.Number("hexadecimal"), Number("912559"))
+	assert.Equal(t, val.Number("leadingDecimalPoint"), Number("0.8675309"))
+	assert.Equal(t, val.Number("andTrailing"), Number("8675309.0"))
+	assert.Equal(t, val.Number("positiveSign"), Number("1"))
+	assert.Equal(t, val.String("andIn", 0), "arrays")
+	assert.Equal(t, val.String("backwardsCompatible"), "with JSON")
+	assert.Equal(t, val.String("$_ünicodeA"), "Aé\x00")
+
+	for _, data := range []string{`{1a: 1}`, `['\1']`, `[0x]`, `[-]`, `{a: 1} x`, `['open]`, `[Infinity]`, `[-NaN]`} {
+		_, err = UnmarshalJSON5([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestUnmarshalJSON5_NonFinite(t *testing.T) {
+	const data = `{inf: [Infinity, +Infinity, -Infinity, NaN]}`
+	_, err := UnmarshalJSON5([]byte(data))
+	var unmarshalErr *UnmarshalError
+	assert.True(t, errors.As(err, &unmarshalErr))
+	assert.Equal(t, unmarshalErr.Offset, int64(7))
+	assert.Equal(t, unmarshalErr.Path, "$.inf[0]")
+
+	val, err := UnmarshalJSON5WithOptions([]byte(data), JSON5Options{NonFinite: NonFiniteNull})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"inf":[null,null,null,null]}`)
+
+	val, err = UnmarshalJSON5WithOptions([]byte(data), JSON5Options{NonFinite: NonFiniteString})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"inf":["Infinity","Infinity","-Infinity","NaN"]}`)
+	back, err := Unmarshal(val.JSON())
+	assert.NoError(t, err)
+	assert.Equal(t, back.String("inf", 2), "-Infinity")
+}
+
+func BenchmarkUnmarshalJSON5(b *testing.B) {
+	var src = []byte("[")
+	for i := 0; i < 20000; i++ {
+		src = append(src, "/* n */ 1.5e3, .5, +1, null,\n"...)
+	}
+	src = append(src, "true]"...)
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalJSON5(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
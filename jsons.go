@@ -3,6 +3,7 @@ package jsons
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 )
 
 type (
@@ -25,11 +26,45 @@ func MarshalIdent(v interface{}, prefix, indent string) ([]byte, error) {
 	return json.MarshalIndent(v, prefix, indent)
 }
 
+// Unmarshal decodes a single JSON value. Anything but whitespace after it is
+// an error wrapping ErrTrailingData; use UnmarshalAll for a stream of values.
 func Unmarshal(data []byte) (val Value, err error) {
 	decoder := json.NewDecoder(bytes.NewReader(data))
 	decoder.UseNumber()
 	if err = decoder.Decode(&val.value); err != nil {
 		return
 	}
+	if err = checkTrailing(data, decoder.InputOffset()); err != nil {
+		val.value = nil
+	}
 	return
-}
\ No newline at end of file
+}
+
+// UnmarshalAll decodes every value of a stream of concatenated JSON values.
+func UnmarshalAll(data []byte) ([]Value, error) {
+	var vals = make([]Value, 0)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	for {
+		var val Value
+		if err := decoder.Decode(&val.value); err == io.EOF {
+			return vals, nil
+		} else if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+}
+
+// checkTrailing reports anything but whitespace after the value that ends at
+// offset.
+func checkTrailing(data []byte, offset int64) error {
+	for i := offset; i < int64(len(data)); i++ {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return &UnmarshalError{Offset: i, Path: "$", Err: ErrTrailingData}
+		}
+	}
+	return nil
+}
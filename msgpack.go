@@ -0,0 +1,327 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MarshalMsgpack encodes the value as MessagePack. Integer Numbers become
+// MessagePack integers and the others floats, []byte becomes bin and Object
+// keys are written sorted.
+func (v Value) MarshalMsgpack() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, v.value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgpack decodes a MessagePack document. Floats keep a fraction so
+// they remain floats, bin becomes []byte and timestamps become Strings in
+// TimeLayout.
+func (v *Value) UnmarshalMsgpack(data []byte) error {
+	var r = binaryReader{format: "msgpack", data: data}
+	val, err := decodeMsgpack(&r)
+	if err != nil {
+		return err
+	}
+	if err = r.end(); err != nil {
+		return err
+	}
+	v.value = val
+	return nil
+}
+
+func encodeMsgpack(buf *bytes.Buffer, src interface{}) error {
+	src, err := binaryValue(src)
+	if err != nil {
+		return err
+	}
+	switch v := src.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case Bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case Number:
+		return msgpackNumber(buf, v)
+	case String:
+		msgpackLength(buf, uint64(len(v)), 0xa0, 31, 0xd9, 0xda)
+		buf.WriteString(string(v))
+	case []byte:
+		msgpackLength(buf, uint64(len(v)), 0, 0, 0xc4, 0xc5)
+		buf.Write(v)
+	case Array:
+		msgpackLength(buf, uint64(len(v)), 0x90, 15, 0, 0xdc)
+		for _, elem := range v {
+			if err = encodeMsgpack(buf, elem); err != nil {
+				return err
+			}
+		}
+	case Object:
+		msgpackLength(buf, uint64(len(v)), 0x80, 15, 0, 0xde)
+		for _, key := range sortedKeys(v) {
+			msgpackLength(buf, uint64(len(key)), 0xa0, 31, 0xd9, 0xda)
+			buf.WriteString(key)
+			if err = encodeMsgpack(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("jsons: msgpack: cannot encode %T", src)
+	}
+	return nil
+}
+
+// msgpackLength writes a length header in the shortest form the type has:
+// fix (when non-zero) up to fixMax, then 8 bit (when non-zero), 16 bit and
+// 32 bit, whose code always follows the 16 bit one.
+func msgpackLength(buf *bytes.Buffer, n uint64, fix byte, fixMax uint64, code8, code16 byte) {
+	var b [5]byte
+	switch {
+	case fix != 0 && n <= fixMax:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.Write([]byte{code8, byte(n)})
+	case n <= math.MaxUint16:
+		b[0] = code16
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		buf.Write(b[:3])
+	default:
+		b[0] = code16 + 1
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		buf.Write(b[:5])
+	}
+}
+
+func msgpackNumber(buf *bytes.Buffer, n Number) error {
+	i, f, err := binaryNumber(n)
+	if err != nil {
+		return err
+	}
+	var b [9]byte
+	switch {
+	case i == nil && isFloat32(f):
+		b[0] = 0xca
+		binary.BigEndian.PutUint32(b[1:], math.Float32bits(float32(f)))
+		buf.Write(b[:5])
+	case i == nil:
+		b[0] = 0xcb
+		binary.BigEndian.PutUint64(b[1:], math.Float64bits(f))
+		buf.Write(b[:9])
+	case i.Sign() >= 0 && i.IsUint64():
+		var u = i.Uint64()
+		switch {
+		case u <= 0x7f:
+			buf.WriteByte(byte(u))
+		case u <= math.MaxUint8:
+			buf.Write([]byte{0xcc, byte(u)})
+		case u <= math.MaxUint16:
+			b[0] = 0xcd
+			binary.BigEndian.PutUint16(b[1:], uint16(u))
+			buf.Write(b[:3])
+		case u <= math.MaxUint32:
+			b[0] = 0xce
+			binary.BigEndian.PutUint32(b[1:], uint32(u))
+			buf.Write(b[:5])
+		default:
+			b[0] = 0xcf
+			binary.BigEndian.PutUint64(b[1:], u)
+			buf.Write(b[:9])
+		}
+	case i.IsInt64():
+		var s = i.Int64()
+		switch {
+		case s >= -32:
+			buf.WriteByte(byte(s))
+		case s >= math.MinInt8:
+			buf.Write([]byte{0xd0, byte(s)})
+		case s >= math.MinInt16:
+			b[0] = 0xd1
+			binary.BigEndian.PutUint16(b[1:], uint16(s))
+			buf.Write(b[:3])
+		case s >= math.MinInt32:
+			b[0] = 0xd2
+			binary.BigEndian.PutUint32(b[1:], uint32(s))
+			buf.Write(b[:5])
+		default:
+			b[0] = 0xd3
+			binary.BigEndian.PutUint64(b[1:], uint64(s))
+			buf.Write(b[:9])
+		}
+	default:
+		return fmt.Errorf("jsons: msgpack: integer %s overflows 64 bits", string(n))
+	}
+	return nil
+}
+
+func decodeMsgpack(r *binaryReader) (interface{}, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c&0xf0 == 0x80:
+		return msgpackMap(r, uint64(c&0x0f))
+	case c&0xf0 == 0x90:
+		return msgpackArray(r, uint64(c&0x0f))
+	case c&0xe0 == 0xa0:
+		b, err := r.next(uint64(c & 0x1f))
+		return string(b), err
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackExt(r, n)
+	case 0xca:
+		u, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(float64(math.Float32frombits(uint32(u))))
+	case 0xcb:
+		u, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return r.float(math.Float64frombits(u))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (c - 0xcc))
+		return json.Number(strconv.FormatUint(u, 10)), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		var size = 1 << (c - 0xd0)
+		u, err := r.uint(size)
+		var shift = uint(64 - 8*size)
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return msgpackExt(r, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(n)
+		return string(b), err
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackArray(r, n)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackMap(r, n)
+	}
+	r.pos--
+	return nil, r.errorf("invalid type 0x%02x", c)
+}
+
+func msgpackArray(r *binaryReader, n uint64) (interface{}, error) {
+	length, err := r.items(n, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	var arr = make([]interface{}, length)
+	for i := range arr {
+		if arr[i], err = decodeMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return arr, nil
+}
+
+func msgpackMap(r *binaryReader, n uint64) (interface{}, error) {
+	length, err := r.items(n, 2)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+	var obj = make(map[string]interface{}, length)
+	for i := 0; i < length; i++ {
+		var offset = r.pos
+		key, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		str, ok := key.(string)
+		if !ok {
+			r.pos = offset
+			return nil, r.errorf("map key is not a string")
+		}
+		if obj[str], err = decodeMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// msgpackExt decodes the timestamp extension (type -1); other extension
+// types have no Value equivalent.
+func msgpackExt(r *binaryReader, n uint64) (interface{}, error) {
+	typ, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) != -1 {
+		return nil, r.errorf("unsupported extension type %d", int8(typ))
+	}
+	var sec, nsec int64
+	switch n {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(b))
+	case 8:
+		u := binary.BigEndian.Uint64(b)
+		nsec, sec = int64(u>>34), int64(u&(1<<34-1))
+	case 12:
+		nsec, sec = int64(binary.BigEndian.Uint32(b)), int64(binary.BigEndian.Uint64(b[4:]))
+	default:
+		return nil, r.errorf("invalid timestamp length %d", n)
+	}
+	return time.Unix(sec, nsec).UTC().Format(TimeLayout), nil
+}
@@ -0,0 +1,72 @@
+package jsons
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_MarshalMsgpack(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"compact": true, "schema": 0}`))
+	data, err := val.MarshalMsgpack()
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(data), "82a7636f6d70616374c3a6736368656d6100")
+
+	for _, c := range []struct {
+		json string
+		hex  string
+	}{
+		{`null`, "c0"},
+		{`-1`, "ff"},
+		{`-33`, "d0df"},
+		{`200`, "ccc8"},
+		{`-40000`, "d2ffff63c0"},
+		{`18446744073709551615`, "cfffffffffffffffff"},
+		{`1.5`, "ca3fc00000"},
+		{`1.1`, "cb3ff199999999999a"},
+		{`2.0`, "ca40000000"},
+		{`[1, "a"]`, "9201a161"},
+	} {
+		val, _ := Unmarshal([]byte(c.json))
+		data, err := val.MarshalMsgpack()
+		assert.NoError(t, err, c.json)
+		assert.Equal(t, hex.EncodeToString(data), c.hex, c.json)
+
+		var back Value
+		assert.NoError(t, back.UnmarshalMsgpack(data), c.json)
+		assert.Equal(t, back.JSONString(), val.JSONString(), c.json)
+	}
+
+	val, _ = Unmarshal([]byte(`[18446744073709551616]`))
+	_, err = val.MarshalMsgpack()
+	assert.Error(t, err)
+
+	for _, n := range []Number{"Infinity", "-Infinity", "NaN"} {
+		_, err = value(n).MarshalMsgpack()
+		assert.Error(t, err, string(n))
+	}
+}
+
+func TestValue_UnmarshalMsgpack(t *testing.T) {
+	val := value(Object{"bin": []byte{1, 2, 3}, "ratio": Number("2.0"), "n": 2, "s": "x"})
+	data, err := val.MarshalMsgpack()
+	assert.NoError(t, err)
+
+	var back Value
+	assert.NoError(t, back.UnmarshalMsgpack(data))
+	assert.Equal(t, back.Object()["bin"], []byte{1, 2, 3})
+	assert.Equal(t, back.Number("ratio"), Number("2.0"))
+	assert.Equal(t, back.Number("n"), Number("2"))
+	assert.Equal(t, back.String("s"), "x")
+
+	// timestamp 32: 2022-05-01T10:20:30Z
+	data, _ = hex.DecodeString("d6ff626e5eee")
+	assert.NoError(t, back.UnmarshalMsgpack(data))
+	assert.Equal(t, back.String(), "2022-05-01T10:20:30Z")
+
+	for _, s := range []string{"", "c1", "92", "a2ff", "dfffffffff", "8101a0", "c0c0", "d401ff", "ca7fc00000", "91cbfff0000000000000"} {
+		data, _ = hex.DecodeString(s)
+		assert.Error(t, back.UnmarshalMsgpack(data), s)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -57,6 +60,25 @@ func (n Number) Uint64() (uint64, error) {
 	return strconv.ParseUint(string(n), 10, 64)
 }
 
+// maxRatExponent bounds the exponents Rat accepts, as 1e1000000000 would
+// take a gigabit to hold exactly.
+const maxRatExponent = 10000
+
+// Rat returns the exact value of n.
+func (n Number) Rat() (*big.Rat, error) {
+	var s = string(n)
+	if s == "" || !json.Valid([]byte(s)) || strings.Trim(s, "-+.0123456789eE") != "" {
+		return nil, fmt.Errorf("jsons: invalid number %q", s)
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if exp, err := strconv.Atoi(s[i+1:]); err != nil || exp > maxRatExponent || exp < -maxRatExponent {
+			return nil, fmt.Errorf("jsons: number %q out of range", s)
+		}
+	}
+	r, _ := new(big.Rat).SetString(s)
+	return r, nil
+}
+
 func (n Number) String() string {
 	return json.Number(n).String()
 }
@@ -0,0 +1,212 @@
+package jsons
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Put sets the last of keys at the path given by the others like Set, but
+// creates missing Objects (for string keys) and Arrays (for int keys) on the
+// way, padding Arrays with null. It fails if the path runs through a value
+// that is neither.
+func (v *Value) Put(keys ...interface{}) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	root, err := put(v.value, keys[:len(keys)-1], keys[len(keys)-1], nil)
+	if err != nil {
+		return err
+	}
+	v.value = root
+	return nil
+}
+
+func put(node interface{}, keys []interface{}, val interface{}, path []interface{}) (interface{}, error) {
+	if len(keys) == 0 {
+		return value(val), nil
+	}
+	for wrapped, ok := node.(Value); ok; wrapped, ok = node.(Value) {
+		node = wrapped.value
+	}
+	switch key := keys[0].(type) {
+	case int:
+		var arr []interface{}
+		switch n := node.(type) {
+		case nil:
+		case Array:
+			arr = n
+		case []interface{}:
+			arr = n
+		default:
+			return nil, fmt.Errorf("jsons: cannot index %s at %s", value(node).Type(), JSONPath(path...))
+		}
+		if key < 0 {
+			return nil, fmt.Errorf("jsons: negative index %d at %s", key, JSONPath(path...))
+		}
+		for len(arr) <= key {
+			arr = append(arr, nil)
+		}
+		elem, err := put(arr[key], keys[1:], val, append(path, key))
+		if err != nil {
+			return nil, err
+		}
+		arr[key] = elem
+		return Array(arr), nil
+	case string:
+		var obj map[string]interface{}
+		switch n := node.(type) {
+		case nil:
+			obj = make(map[string]interface{})
+		case Object:
+			obj = n
+		case map[string]interface{}:
+			obj = n
+		default:
+			return nil, fmt.Errorf("jsons: cannot set key %q in %s at %s", key, value(node).Type(), JSONPath(path...))
+		}
+		if obj == nil {
+			obj = make(map[string]interface{})
+		}
+		elem, err := put(obj[key], keys[1:], val, append(path, key))
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = elem
+		return Object(obj), nil
+	}
+	return nil, fmt.Errorf("jsons: invalid key %v", keys[0])
+}
+
+// Overlay applies configuration overrides from environment variables and
+// command-line arguments onto a Value. Override values are parsed as JSON
+// when possible and used as Strings otherwise, so "8080" sets a Number and
+// "localhost" a String.
+type Overlay struct {
+	// EnvPrefix selects the environment variables to apply, e.g. "APP_".
+	// Variables are ignored when it is empty.
+	EnvPrefix string
+	// EnvSeparator separates nested keys in variable names, "__" by
+	// default, so APP_DB__HOST sets db.host. Keys match existing keys
+	// ignoring case and are otherwise lower-cased; numeric keys index
+	// Arrays.
+	EnvSeparator string
+	// Environ holds "KEY=value" pairs, os.Environ() if nil. Variables
+	// are applied shallowest path first, then by name, so APP_DB={...}
+	// is refined by APP_DB__HOST.
+	Environ []string
+	// Args holds "--set a.b=1" or "--set=a.b=1" overrides, whose paths
+	// are in Flatten's dot notation. Other arguments are ignored.
+	Args []string
+}
+
+// Apply overlays the environment, then the arguments, onto v.
+func (o Overlay) Apply(v *Value) error {
+	if o.EnvPrefix != "" {
+		var environ = o.Environ
+		if environ == nil {
+			environ = os.Environ()
+		}
+		var sep = o.EnvSeparator
+		if sep == "" {
+			sep = "__"
+		}
+		var vars = make(map[string]string)
+		var names = make([]string, 0)
+		for _, env := range environ {
+			name, val, ok := strings.Cut(env, "=")
+			if !ok || !strings.HasPrefix(name, o.EnvPrefix) || name == o.EnvPrefix {
+				continue
+			}
+			if _, dup := vars[name]; !dup {
+				names = append(names, name)
+			}
+			vars[name] = val
+		}
+		// Shallower paths go first so that deeper ones refine them
+		// whatever the order of the environment.
+		sort.Slice(names, func(i, j int) bool {
+			var di, dj = strings.Count(names[i], sep), strings.Count(names[j], sep)
+			if di != dj {
+				return di < dj
+			}
+			return names[i] < names[j]
+		})
+		for _, name := range names {
+			var keys = envKeys(*v, strings.Split(name[len(o.EnvPrefix):], sep))
+			if err := v.Put(append(keys, overrideValue(vars[name]))...); err != nil {
+				return fmt.Errorf("jsons: %s: %v", name, strings.TrimPrefix(err.Error(), "jsons: "))
+			}
+		}
+	}
+
+	for i := 0; i < len(o.Args); i++ {
+		var arg = o.Args[i]
+		switch {
+		case arg == "--set" || arg == "-set":
+			if i+1 == len(o.Args) {
+				return fmt.Errorf("jsons: %s needs a path=value argument", arg)
+			}
+			i++
+			arg = o.Args[i]
+		case strings.HasPrefix(arg, "--set="):
+			arg = arg[len("--set="):]
+		case strings.HasPrefix(arg, "-set="):
+			arg = arg[len("-set="):]
+		default:
+			continue
+		}
+		path, val, ok := strings.Cut(arg, "=")
+		if !ok || path == "" {
+			return fmt.Errorf("jsons: invalid override %q, want path=value", arg)
+		}
+		keys, err := Flattener{}.parse(path)
+		if err != nil {
+			return err
+		}
+		if err = v.Put(append(keys, overrideValue(val))...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envKeys maps the parts of a variable name to keys, matching existing keys
+// of base ignoring case.
+func envKeys(base Value, parts []string) []interface{} {
+	var keys = make([]interface{}, len(parts))
+	var node = base
+	for i, part := range parts {
+		var key interface{} = strings.ToLower(part)
+		if n, ok := indexKey(part); ok && !node.IsObject() {
+			key = n
+		} else {
+			for _, existing := range node.Keys() {
+				if strings.EqualFold(existing, part) {
+					key = existing
+					break
+				}
+			}
+		}
+		keys[i] = key
+		node = node.Get(key)
+	}
+	return keys
+}
+
+func indexKey(s string) (int, bool) {
+	keys, err := Flattener{}.parse(s)
+	if err != nil || len(keys) != 1 {
+		return 0, false
+	}
+	n, ok := keys[0].(int)
+	return n, ok
+}
+
+func overrideValue(s string) interface{} {
+	if val, err := Unmarshal([]byte(s)); err == nil {
+		return val
+	}
+	return s
+}
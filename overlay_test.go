@@ -0,0 +1,63 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_Put(t *testing.T) {
+	var val Value
+	assert.NoError(t, val.Put("db", "host", "localhost"))
+	assert.NoError(t, val.Put("db", "replicas", 1, "port", 5432))
+	assert.NoError(t, val.Put("db", "host", "db.internal"))
+	assert.Equal(t, val.JSONString(), `{"db":{"host":"db.internal","replicas":[null,{"port":5432}]}}`)
+
+	assert.Error(t, val.Put("db", "host", "name", "x"))
+	assert.Error(t, val.Put("db", 0, "x"))
+	assert.Error(t, val.Put("db", "replicas", -1, "x"))
+}
+
+func TestOverlay_Apply(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"db": {"host": "localhost", "maxConns": 10}, "servers": [{"port": 80}]}`))
+	err := Overlay{
+		EnvPrefix: "APP_",
+		Environ: []string{
+			"APP_DB__HOST=db.internal",
+			"APP_DB__MAXCONNS=20",
+			"APP_SERVERS__0__TLS=true",
+			"APP_LOG__LEVEL=debug",
+			"HOME=/root",
+		},
+		Args: []string{"serve", "--set", "db.port=5433", "--set=log.tags=[\"a\",\"b\"]", "-v"},
+	}.Apply(&val)
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"db":{"host":"db.internal","maxConns":20,"port":5433},"log":{"level":"debug","tags":["a","b"]},"servers":[{"port":80,"tls":true}]}`)
+
+	// Arguments are applied after the environment.
+	val = Value{}
+	err = Overlay{EnvPrefix: "APP_", Environ: []string{"APP_A=1"}, Args: []string{"--set", "a=two"}}.Apply(&val)
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"a":"two"}`)
+
+	// Shallower variables apply first whatever the environment order.
+	for _, environ := range [][]string{
+		{"APP_A__B=2", `APP_A={"c":1}`},
+		{`APP_A={"c":1}`, "APP_A__B=2"},
+	} {
+		val = Value{}
+		assert.NoError(t, Overlay{EnvPrefix: "APP_", Environ: environ}.Apply(&val))
+		assert.Equal(t, val.JSONString(), `{"a":{"b":2,"c":1}}`)
+	}
+
+	for _, o := range []Overlay{
+		{Args: []string{"--set"}},
+		{Args: []string{"--set", "a"}},
+		{Args: []string{"--set", "a=1", "--set", "a.b=2"}},
+		{EnvPrefix: "APP_", Environ: []string{"APP_A=1", "APP_A__B=2"}},
+		{EnvPrefix: "APP_", Environ: []string{"APP_A__B=2", "APP_A=1"}},
+	} {
+		val = Value{}
+		assert.Error(t, o.Apply(&val))
+	}
+}
@@ -0,0 +1,343 @@
+package jsons
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Diff returns the RFC 6902 JSON Patch turning a into b, an Array of
+// operations. Object members are compared in key order and Arrays index by
+// index. Numbers are equal if their values are, so 1 and 1.0 do not differ.
+func Diff(a, b Value) (Array, error) {
+	x, err := normalizeTree(a.value)
+	if err != nil {
+		return nil, err
+	}
+	y, err := normalizeTree(b.value)
+	if err != nil {
+		return nil, err
+	}
+	return diff(make(Array, 0), nil, x, y), nil
+}
+
+func diff(ops Array, path []string, a, b interface{}) Array {
+	if jsonEqual(a, b) {
+		return ops
+	}
+	var at = func(key string) []string {
+		return append(append(make([]string, 0, len(path)+1), path...), key)
+	}
+	switch x := a.(type) {
+	case Object:
+		y, ok := b.(Object)
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(x) {
+			if w, ok := y[k]; ok {
+				ops = diff(ops, at(k), x[k], w)
+			} else {
+				ops = append(ops, patchOp("remove", at(k), nil))
+			}
+		}
+		for _, k := range sortedKeys(y) {
+			if _, ok := x[k]; !ok {
+				ops = append(ops, patchOp("add", at(k), y[k]))
+			}
+		}
+		return ops
+	case Array:
+		y, ok := b.(Array)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(x) && i < len(y); i++ {
+			ops = diff(ops, at(strconv.Itoa(i)), x[i], y[i])
+		}
+		for i := len(x); i < len(y); i++ {
+			ops = append(ops, patchOp("add", at(strconv.Itoa(i)), y[i]))
+		}
+		for i := len(x) - 1; i >= len(y); i-- {
+			ops = append(ops, patchOp("remove", at(strconv.Itoa(i)), nil))
+		}
+		return ops
+	}
+	return append(ops, patchOp("replace", path, b))
+}
+
+func patchOp(op string, path []string, val interface{}) Object {
+	var obj = Object{"op": String(op), "path": String(jsonPointer(path))}
+	if op != "remove" {
+		obj["value"] = val
+	}
+	return obj
+}
+
+// jsonEqual reports whether a and b hold the same JSON, Numbers being equal
+// if their values are.
+func jsonEqual(a, b interface{}) bool {
+	a, errA := normalize(a)
+	b, errB := normalize(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	switch x := a.(type) {
+	case Object:
+		y, ok := b.(Object)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case Array:
+		y, ok := b.(Array)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case Number:
+		y, ok := b.(Number)
+		if !ok {
+			return false
+		}
+		r, errX := x.Rat()
+		s, errY := y.Rat()
+		if errX != nil || errY != nil {
+			return x == y
+		}
+		return r.Cmp(s) == 0
+	}
+	return a == b
+}
+
+// jsonPointer formats an RFC 6901 JSON Pointer.
+func jsonPointer(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
+	}
+	return b.String()
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its reference tokens.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("jsons: JSON pointer %q does not start with /", s)
+	}
+	var tokens = strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// Patch applies an RFC 6902 JSON Patch, an Array of operations, through
+// Set, Insert and Delete, so that the members it does not touch keep their
+// order, layout and comments. If an operation fails the document is left
+// as it was.
+func (d *Document) Patch(patch Value) error {
+	if !patch.IsArray() {
+		return errors.New("jsons: patch is not an array of operations")
+	}
+	var data, root = d.data, d.root
+	for i, op := range patch.Array() {
+		if err := d.patchOp(value(op)); err != nil {
+			d.data, d.root = data, root
+			return fmt.Errorf("jsons: patch operation %d: %v", i, strings.TrimPrefix(err.Error(), "jsons: "))
+		}
+	}
+	return nil
+}
+
+func (d *Document) patchOp(op Value) error {
+	if !op.IsObject() {
+		return errors.New("operation is not an object")
+	}
+	var pointer = func(name string) (string, error) {
+		if !op.IsString(name) {
+			return "", fmt.Errorf("missing %q", name)
+		}
+		return op.String(name), nil
+	}
+	path, err := pointer("path")
+	if err != nil {
+		return err
+	}
+	var val = op.Get("value")
+	var name = op.String("op")
+	switch name {
+	case "add", "replace", "test":
+		if !op.Exist("value") {
+			return errors.New(`missing "value"`)
+		}
+	case "move", "copy":
+		from, err := pointer("from")
+		if err != nil {
+			return err
+		}
+		keys, err := d.pointerKeys(from)
+		if err != nil {
+			return err
+		}
+		if !d.Exist(keys...) {
+			return fmt.Errorf("%s: %w", from, ErrNotFound)
+		}
+		if val, err = d.Value(keys...); err != nil {
+			return err
+		}
+		if name == "copy" {
+			break
+		}
+		if strings.HasPrefix(path, from+"/") {
+			return fmt.Errorf("cannot move %s into itself", from)
+		}
+		if len(keys) == 0 {
+			return d.Set(val)
+		}
+		if err = d.Delete(keys...); err != nil {
+			return err
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown op %q", name)
+	}
+
+	keys, err := d.pointerKeys(path)
+	if err != nil {
+		return err
+	}
+	if name != "add" && name != "move" && name != "copy" && !d.Exist(keys...) {
+		return fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	switch name {
+	case "remove":
+		return d.Delete(keys...)
+	case "replace":
+		return d.Set(append(keys, val)...)
+	case "test":
+		cur, err := d.Value(keys...)
+		if err != nil {
+			return err
+		}
+		if !jsonEqual(cur, val) {
+			return fmt.Errorf("test failed at %q", path)
+		}
+		return nil
+	}
+	if len(keys) == 0 {
+		return d.Set(val)
+	}
+	parent, err := d.lookup(keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	if idx, ok := keys[len(keys)-1].(int); ok && parent.array {
+		if idx > len(parent.members) {
+			return fmt.Errorf("%s: index %d out of range", path, idx)
+		}
+		return d.Insert(append(keys, val)...)
+	}
+	if !parent.object {
+		return fmt.Errorf("%s: parent is not a container", path)
+	}
+	return d.Set(append(keys, val)...)
+}
+
+// pointerKeys resolves an RFC 6901 JSON Pointer to keys: tokens key
+// Objects and index Arrays, where "-" is the end of the Array. The value at
+// the last key need not exist.
+func (d *Document) pointerKeys(pointer string) ([]interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	var keys = make([]interface{}, 0, len(tokens))
+	var n = d.root
+	for i, tok := range tokens {
+		switch {
+		case n == nil:
+			return nil, fmt.Errorf("jsons: %s: %w", jsonPointer(tokens[:i]), ErrNotFound)
+		case n.object:
+			keys = append(keys, tok)
+		case n.array:
+			var idx = len(n.members)
+			if tok != "-" {
+				if idx, err = strconv.Atoi(tok); err != nil || idx < 0 || tok != strconv.Itoa(idx) {
+					return nil, fmt.Errorf("jsons: %s: invalid array index %q", jsonPointer(tokens[:i+1]), tok)
+				}
+			}
+			keys = append(keys, idx)
+		default:
+			return nil, fmt.Errorf("jsons: %s: %w", jsonPointer(tokens[:i+1]), ErrNotFound)
+		}
+		if idx := n.find(keys[i]); idx >= 0 {
+			n = n.members[idx].value
+		} else {
+			n = nil
+		}
+	}
+	return keys, nil
+}
+
+// MergePatch applies an RFC 7396 JSON Merge Patch through Set and Delete,
+// so that the members it does not touch keep their order, layout and
+// comments. New members are added in key order.
+func (d *Document) MergePatch(patch Value) error {
+	var data, root = d.data, d.root
+	if err := d.merge(nil, patch); err != nil {
+		d.data, d.root = data, root
+		return err
+	}
+	return nil
+}
+
+func (d *Document) merge(keys []interface{}, patch Value) error {
+	if n, err := d.lookup(keys); err != nil || !n.object || !patch.IsObject() {
+		return d.Set(append(keys, mergeNew(patch))...)
+	}
+	for _, key := range sortedKeys(patch.Object()) {
+		var at = append(keys[:len(keys):len(keys)], key)
+		if patch.IsNull(key) {
+			if d.Exist(at...) {
+				if err := d.Delete(at...); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := d.merge(at, patch.Get(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeNew returns what a Merge Patch makes of a value that is not an
+// object: the patch without its null members.
+func mergeNew(patch Value) interface{} {
+	if !patch.IsObject() {
+		return patch.value
+	}
+	var obj = make(Object)
+	for _, key := range sortedKeys(patch.Object()) {
+		if !patch.IsNull(key) {
+			obj[key] = mergeNew(patch.Get(key))
+		}
+	}
+	return obj
+}
@@ -0,0 +1,98 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDiff(t *testing.T) {
+	a, _ := Unmarshal([]byte(`{"a": [1, 2, 3], "b": {"c": 1.0}, "d": "x"}`))
+	b, _ := Unmarshal([]byte(`{"a": [1, 4], "b": {"c": 1}, "e/f": null}`))
+	patch, err := Diff(a, b)
+	assert.NoError(t, err)
+	assert.Equal(t, Value{value: patch}.JSONString(), `[{"op":"replace","path":"/a/1","value":4},{"op":"remove","path":"/a/2"},{"op":"remove","path":"/d"},{"op":"add","path":"/e~1f","value":null}]`)
+
+	patch, err = Diff(a, a)
+	assert.NoError(t, err)
+	assert.Equal(t, len(patch), 0)
+
+	doc, err := ParseDocument(a.JSON())
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Patch(Value{value: patch}))
+	patch, _ = Diff(a, b)
+	assert.NoError(t, doc.Patch(Value{value: patch}))
+	val, _ := doc.Value()
+	patch, _ = Diff(val, b)
+	assert.Equal(t, len(patch), 0)
+}
+
+func TestDocument_Patch(t *testing.T) {
+	doc, err := ParseDocument([]byte("{\n  // keep\n  \"z\": [1, 2],\n  \"a\": 1.50\n}\n"))
+	assert.NoError(t, err)
+	patch, _ := Unmarshal([]byte(`[
+		{"op": "add", "path": "/z/-", "value": 3},
+		{"op": "replace", "path": "/a", "value": 2.50},
+		{"op": "add", "path": "/m", "value": {"k": true}}
+	]`))
+	assert.NoError(t, doc.Patch(patch))
+	assert.Equal(t, doc.String(), "{\n  // keep\n  \"z\": [1, 2, 3],\n  \"a\": 2.50,\n  \"m\": {\n    \"k\": true\n  }\n}\n")
+
+	const src = `{"a":[1,2],"b":{"c":true}}`
+	for _, c := range []struct {
+		patch string
+		want  string
+	}{
+		{`[{"op":"add","path":"/a/0","value":0}]`, `{"a":[0,1,2],"b":{"c":true}}`},
+		{`[{"op":"remove","path":"/a/0"}]`, `{"a":[2],"b":{"c":true}}`},
+		{`[{"op":"move","from":"/b/c","path":"/a/1"}]`, `{"a":[1,true,2],"b":{}}`},
+		{`[{"op":"move","from":"/a/0","path":"/a/1"}]`, `{"a":[2,1],"b":{"c":true}}`},
+		{`[{"op":"copy","from":"/b","path":"/d"}]`, `{"a":[1,2],"b":{"c":true},"d":{"c":true}}`},
+		{`[{"op":"test","path":"/a/1","value":2.0},{"op":"replace","path":"","value":1}]`, `1`},
+	} {
+		doc, err := ParseDocument([]byte(src))
+		assert.NoError(t, err)
+		patch, _ := Unmarshal([]byte(c.patch))
+		assert.NoError(t, doc.Patch(patch), c.patch)
+		assert.Equal(t, doc.String(), c.want, c.patch)
+	}
+
+	for _, p := range []string{
+		`[{"op":"test","path":"/a/1","value":3}]`,
+		`[{"op":"remove","path":"/x"}]`,
+		`[{"op":"replace","path":"/a/2","value":3}]`,
+		`[{"op":"add","path":"/a/01","value":3}]`,
+		`[{"op":"add","path":"/a/3","value":3}]`,
+		`[{"op":"add","path":"/x/y","value":3}]`,
+		`[{"op":"move","from":"/b","path":"/b/d"}]`,
+		`[{"op":"remove","path":""}]`,
+		`[{"op":"frob","path":""}]`,
+		`[{"op":"add","path":"a","value":1}]`,
+		`[{"op":"add","path":"/a/-"}]`,
+		`[{"op":"remove","path":"/a/0"},{"op":"remove","path":"/x"}]`,
+		`{}`,
+	} {
+		doc, err := ParseDocument([]byte(src))
+		assert.NoError(t, err)
+		patch, _ := Unmarshal([]byte(p))
+		assert.Error(t, doc.Patch(patch), p)
+		assert.Equal(t, doc.String(), src, p)
+	}
+}
+
+func TestDocument_MergePatch(t *testing.T) {
+	doc, err := ParseDocument([]byte("{\n  \"z\": {\"b\": 1, \"keep\": 2, \"x\": 3},\n  \"d\": 2, // two\n  \"a\": 1.50\n}\n"))
+	assert.NoError(t, err)
+	patch, _ := Unmarshal([]byte(`{"z": {"b": null, "c": 1.50}, "d": null, "y": {"n": null, "m": [1]}, "a": 1.50}`))
+	assert.NoError(t, doc.MergePatch(patch))
+	assert.Equal(t, doc.String(), "{\n  \"z\": {\"keep\": 2, \"x\": 3, \"c\": 1.50},\n  \"a\": 1.50,\n  \"y\": {\n    \"m\": [\n      1\n    ]\n  }\n}\n")
+
+	doc, _ = ParseDocument([]byte(`{"a":[1]}`))
+	patch, _ = Unmarshal([]byte(`{"a":{"b":null,"c":1}}`))
+	assert.NoError(t, doc.MergePatch(patch))
+	assert.Equal(t, doc.String(), `{"a":{"c":1}}`)
+
+	patch, _ = Unmarshal([]byte(`[1]`))
+	assert.NoError(t, doc.MergePatch(patch))
+	assert.Equal(t, doc.String(), `[1]`)
+}
@@ -21,3 +21,35 @@ func MysqlPath(keys ...interface{}) string {
 	}
 	return path
 }
+
+func JSONPath(keys ...interface{}) string {
+	var path = "$"
+	for _, k := range keys {
+		switch k := k.(type) {
+		case int:
+			path += fmt.Sprintf("[%d]", k)
+		case string:
+			if isIdentifier(k) {
+				path += "." + k
+			} else {
+				path += fmt.Sprintf("[%s]", strconv.Quote(k))
+			}
+		}
+	}
+	return path
+}
+
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
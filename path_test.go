@@ -17,3 +17,11 @@ func TestMysqlPath(t *testing.T) {
 	assert.Equal(t, MysqlPath("a", 1, "b", 2), `'$."a".[1]."b".[2]'`)
 	assert.Equal(t, MysqlPath(1, "a", 2, "b"), `'$.[1]."a".[2]."b"'`)
 }
+
+func TestJSONPath(t *testing.T) {
+	assert.Equal(t, JSONPath(), "$")
+	assert.Equal(t, JSONPath(true, 1.1), "$")
+	assert.Equal(t, JSONPath("a", 1, "b"), "$.a[1].b")
+	assert.Equal(t, JSONPath("a b", "_c1", "1d"), `$["a b"]._c1["1d"]`)
+	assert.Equal(t, JSONPath(1, 2, ""), `$[1][2][""]`)
+}
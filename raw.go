@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -236,3 +237,40 @@ func (r Raw) JSONValue(keys ...interface{}) Value {
 func (r Raw) JSONString(keys ...interface{}) string {
 	return string(r.JSON(keys...))
 }
+
+// edit splices r in place through a Document, leaving the bytes outside the
+// edited member untouched. Document also accepts JSONC, so r is checked to be
+// strict JSON first.
+func (r *Raw) edit(fn func(doc *Document) error) error {
+	if !r.IsValid() {
+		var v interface{}
+		return fmt.Errorf("jsons: raw is not valid JSON: %v", json.Unmarshal(*r, &v))
+	}
+	doc, err := ParseDocument(*r)
+	if err != nil {
+		return err
+	}
+	if err = fn(doc); err != nil {
+		return err
+	}
+	*r = doc.Bytes()
+	return nil
+}
+
+func (r *Raw) Set(keys ...interface{}) error {
+	return r.edit(func(doc *Document) error {
+		return doc.Set(keys...)
+	})
+}
+
+func (r *Raw) Insert(keys ...interface{}) error {
+	return r.edit(func(doc *Document) error {
+		return doc.Insert(keys...)
+	})
+}
+
+func (r *Raw) Delete(keys ...interface{}) error {
+	return r.edit(func(doc *Document) error {
+		return doc.Delete(keys...)
+	})
+}
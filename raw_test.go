@@ -67,3 +67,36 @@ func TestRaw(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestRaw_Edit(t *testing.T) {
+	raw := Raw(`{
+  "b": 1.50,
+  "a": {"x": [1, 2]},
+  "z": null
+}`)
+	assert.NoError(t, raw.Set("b", Number("2.50")))
+	assert.NoError(t, raw.Set("a", "y", "new"))
+	assert.NoError(t, raw.Insert("a", "x", 0, 0))
+	assert.NoError(t, raw.Delete("z"))
+	assert.Equal(t, string(raw), `{
+  "b": 2.50,
+  "a": {"x": [0, 1, 2], "y": "new"}
+}`)
+	assert.True(t, raw.IsValid())
+
+	assert.Error(t, raw.Set("b", "c", 1))
+	assert.Error(t, raw.Delete("missing"))
+	assert.Error(t, raw.Insert("b", 0, 1))
+
+	raw = Raw(`[1,2,3]`)
+	assert.NoError(t, raw.Set(1, Raw(`{"k": [true]}`)))
+	assert.NoError(t, raw.Delete(2))
+	assert.NoError(t, raw.Set(Object{"all": "replaced"}))
+	assert.Equal(t, string(raw), `{"all":"replaced"}`)
+
+	raw = Raw(`{/*c*/"a":1,}`)
+	assert.Error(t, raw.Set("a", 2))
+	assert.Error(t, raw.Insert("b", 2))
+	assert.Error(t, raw.Delete("a"))
+	assert.Equal(t, string(raw), `{/*c*/"a":1,}`)
+}
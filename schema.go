@@ -0,0 +1,427 @@
+package jsons
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxSchemaDepth bounds the nesting of schemas a validation passes through,
+// so that a $ref cycle fails instead of recursing forever.
+const maxSchemaDepth = 1000
+
+// Schema is a compiled JSON Schema. It supports the keywords of draft 7
+// other than format, content*, dependencies and remote $refs; numeric
+// bounds and multipleOf are compared exactly. It is safe for concurrent
+// use.
+type Schema struct {
+	root    interface{}
+	mu      sync.Mutex
+	regexps map[string]*regexp.Regexp
+}
+
+// SchemaError is a violation of a Schema by the value at Path, a JSONPath.
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// CompileSchema compiles a JSON Schema, an Object or a Bool.
+func CompileSchema(schema Value) (*Schema, error) {
+	root, err := normalizeTree(schema.value)
+	if err != nil {
+		return nil, err
+	}
+	switch root.(type) {
+	case Object, Bool:
+		return &Schema{root: root}, nil
+	}
+	return nil, errors.New("jsons: schema is not an object or boolean")
+}
+
+// Validate returns the violations of v, none if it is valid. The error
+// reports a schema that cannot be applied, such as one with an invalid
+// pattern or a $ref that does not resolve.
+func (s *Schema) Validate(v Value) ([]SchemaError, error) {
+	inst, err := normalizeTree(v.value)
+	if err != nil {
+		return nil, err
+	}
+	var c = schemaCheck{schema: s}
+	var errs = c.validate(s.root, inst, nil)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return errs, nil
+}
+
+// schemaCheck is the state of one validation.
+type schemaCheck struct {
+	schema *Schema
+	depth  int
+	err    error
+}
+
+func (c *schemaCheck) validate(schema, inst interface{}, path []interface{}) []SchemaError {
+	if c.err != nil {
+		return nil
+	}
+	if c.depth++; c.depth > maxSchemaDepth {
+		c.err = fmt.Errorf("jsons: schema nests deeper than %d levels", maxSchemaDepth)
+		return nil
+	}
+	defer func() { c.depth-- }()
+
+	var r = schemaReport{path: path}
+	switch s := schema.(type) {
+	case Bool:
+		if !s {
+			r.fail("no value is allowed")
+		}
+		return r.errs
+	case Object:
+		if ref, ok := s["$ref"].(String); ok {
+			target, err := c.resolve(string(ref))
+			if err != nil {
+				c.err = err
+				return nil
+			}
+			return c.validate(target, inst, path)
+		}
+		c.generic(&r, s, inst)
+		switch x := inst.(type) {
+		case Number:
+			c.number(&r, s, x)
+		case String:
+			c.string(&r, s, string(x))
+		case Array:
+			c.array(&r, s, x)
+		case Object:
+			c.object(&r, s, x)
+		}
+		return r.errs
+	}
+	c.err = fmt.Errorf("jsons: schema at %s is not an object or boolean", JSONPath(path...))
+	return nil
+}
+
+// schemaReport collects the violations of one value against one schema.
+type schemaReport struct {
+	path []interface{}
+	errs []SchemaError
+}
+
+func (r *schemaReport) fail(format string, args ...interface{}) {
+	r.errs = append(r.errs, SchemaError{Path: JSONPath(r.path...), Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *schemaReport) add(errs []SchemaError) {
+	r.errs = append(r.errs, errs...)
+}
+
+// resolve follows a $ref to "#" or a JSON Pointer within the schema.
+func (c *schemaCheck) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("jsons: unsupported $ref %q", ref)
+	}
+	tokens, err := parsePointer(ref[1:])
+	if err != nil {
+		return nil, err
+	}
+	var target = c.schema.root
+	for _, tok := range tokens {
+		var next interface{}
+		var ok bool
+		switch node := target.(type) {
+		case Object:
+			next, ok = node[tok]
+		case Array:
+			i, err := strconv.Atoi(tok)
+			if ok = err == nil && tok == strconv.Itoa(i) && i >= 0 && i < len(node); ok {
+				next = node[i]
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("jsons: $ref %q: %w", ref, ErrNotFound)
+		}
+		target = next
+	}
+	return target, nil
+}
+
+// generic applies the keywords that apply to every type.
+func (c *schemaCheck) generic(r *schemaReport, s Object, inst interface{}) {
+	if t, ok := s["type"]; ok && !matchesType(t, inst) {
+		r.fail("expected %s, got %s", typeList(t), schemaType(inst))
+	}
+	if enum, ok := s["enum"].(Array); ok {
+		var found bool
+		for _, e := range enum {
+			found = found || jsonEqual(e, inst)
+		}
+		if !found {
+			r.fail("value is not one of the enum")
+		}
+	}
+	if want, ok := s["const"]; ok && !jsonEqual(want, inst) {
+		r.fail("value is not %s", value(want).JSON())
+	}
+	if all, ok := s["allOf"].(Array); ok {
+		for _, sub := range all {
+			r.add(c.validate(sub, inst, r.path))
+		}
+	}
+	if anyOf, ok := s["anyOf"].(Array); ok && c.matches(anyOf, inst) == 0 {
+		r.fail("value matches none of anyOf")
+	}
+	if one, ok := s["oneOf"].(Array); ok {
+		if n := c.matches(one, inst); n != 1 {
+			r.fail("value matches %d of oneOf instead of 1", n)
+		}
+	}
+	if not, ok := s["not"]; ok && len(c.validate(not, inst, nil)) == 0 {
+		r.fail("value matches not")
+	}
+	if cond, ok := s["if"]; ok {
+		var branch = "else"
+		if len(c.validate(cond, inst, nil)) == 0 {
+			branch = "then"
+		}
+		if sub, ok := s[branch]; ok {
+			r.add(c.validate(sub, inst, r.path))
+		}
+	}
+}
+
+// matches counts the schemas inst is valid against.
+func (c *schemaCheck) matches(schemas Array, inst interface{}) int {
+	var n int
+	for _, sub := range schemas {
+		if len(c.validate(sub, inst, nil)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *schemaCheck) number(r *schemaReport, s Object, n Number) {
+	x, err := n.Rat()
+	if err != nil {
+		return
+	}
+	var bound = func(name string, ok func(cmp int) bool, msg string) {
+		if b := schemaRat(s[name]); b != nil && !ok(x.Cmp(b)) {
+			r.fail("%s is %s %s", n, msg, s[name])
+		}
+	}
+	bound("minimum", func(cmp int) bool { return cmp >= 0 }, "less than")
+	bound("maximum", func(cmp int) bool { return cmp <= 0 }, "greater than")
+	bound("exclusiveMinimum", func(cmp int) bool { return cmp > 0 }, "not greater than")
+	bound("exclusiveMaximum", func(cmp int) bool { return cmp < 0 }, "not less than")
+	if m := schemaRat(s["multipleOf"]); m != nil && m.Sign() > 0 {
+		if !new(big.Rat).Quo(x, m).IsInt() {
+			r.fail("%s is not a multiple of %s", n, s["multipleOf"])
+		}
+	}
+}
+
+func (c *schemaCheck) string(r *schemaReport, s Object, str string) {
+	var n = utf8.RuneCountInString(str)
+	if min, ok := schemaCount(s["minLength"]); ok && n < min {
+		r.fail("string is shorter than %d characters", min)
+	}
+	if max, ok := schemaCount(s["maxLength"]); ok && n > max {
+		r.fail("string is longer than %d characters", max)
+	}
+	if pattern, ok := s["pattern"].(String); ok {
+		if re := c.regexp(string(pattern)); re != nil && !re.MatchString(str) {
+			r.fail("string does not match %q", pattern)
+		}
+	}
+}
+
+func (c *schemaCheck) array(r *schemaReport, s Object, arr Array) {
+	var at = func(i int) []interface{} {
+		return append(append(make([]interface{}, 0, len(r.path)+1), r.path...), i)
+	}
+	switch items := s["items"].(type) {
+	case Array:
+		for i, elem := range arr {
+			if i < len(items) {
+				r.add(c.validate(items[i], elem, at(i)))
+			} else if extra, ok := s["additionalItems"]; ok {
+				r.add(c.validate(extra, elem, at(i)))
+			}
+		}
+	case nil:
+	default:
+		for i, elem := range arr {
+			r.add(c.validate(items, elem, at(i)))
+		}
+	}
+	if min, ok := schemaCount(s["minItems"]); ok && len(arr) < min {
+		r.fail("array has fewer than %d items", min)
+	}
+	if max, ok := schemaCount(s["maxItems"]); ok && len(arr) > max {
+		r.fail("array has more than %d items", max)
+	}
+	if unique, _ := s["uniqueItems"].(Bool); unique {
+	dups:
+		for i := range arr {
+			for j := 0; j < i; j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					r.fail("items %d and %d are equal", j, i)
+					break dups
+				}
+			}
+		}
+	}
+	if contains, ok := s["contains"]; ok {
+		var found bool
+		for _, elem := range arr {
+			found = found || len(c.validate(contains, elem, nil)) == 0
+		}
+		if !found {
+			r.fail("array contains no matching item")
+		}
+	}
+}
+
+func (c *schemaCheck) object(r *schemaReport, s Object, obj Object) {
+	var at = func(key string) []interface{} {
+		return append(append(make([]interface{}, 0, len(r.path)+1), r.path...), key)
+	}
+	props, _ := s["properties"].(Object)
+	patterns, _ := s["patternProperties"].(Object)
+	extra, hasExtra := s["additionalProperties"]
+	for _, key := range sortedKeys(obj) {
+		var matched bool
+		if sub, ok := props[key]; ok {
+			matched = true
+			r.add(c.validate(sub, obj[key], at(key)))
+		}
+		for _, pattern := range sortedKeys(patterns) {
+			if re := c.regexp(pattern); re != nil && re.MatchString(key) {
+				matched = true
+				r.add(c.validate(patterns[pattern], obj[key], at(key)))
+			}
+		}
+		if !matched && hasExtra {
+			if allowed, ok := extra.(Bool); ok && !bool(allowed) {
+				r.fail("property %q is not allowed", key)
+			} else {
+				r.add(c.validate(extra, obj[key], at(key)))
+			}
+		}
+		if names, ok := s["propertyNames"]; ok && len(c.validate(names, String(key), nil)) > 0 {
+			r.fail("property name %q is invalid", key)
+		}
+	}
+	if required, ok := s["required"].(Array); ok {
+		for _, name := range required {
+			if key, ok := name.(String); ok {
+				if _, ok := obj[string(key)]; !ok {
+					r.fail("property %q is required", key)
+				}
+			}
+		}
+	}
+	if min, ok := schemaCount(s["minProperties"]); ok && len(obj) < min {
+		r.fail("object has fewer than %d properties", min)
+	}
+	if max, ok := schemaCount(s["maxProperties"]); ok && len(obj) > max {
+		r.fail("object has more than %d properties", max)
+	}
+}
+
+// regexp compiles a pattern once per Schema.
+func (c *schemaCheck) regexp(pattern string) *regexp.Regexp {
+	var s = c.schema
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if re, ok := s.regexps[pattern]; ok {
+		return re
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		c.err = fmt.Errorf("jsons: invalid pattern %q: %v", pattern, err)
+		return nil
+	}
+	if s.regexps == nil {
+		s.regexps = make(map[string]*regexp.Regexp)
+	}
+	s.regexps[pattern] = re
+	return re
+}
+
+// schemaType names the type of inst as JSON Schema does.
+func schemaType(inst interface{}) string {
+	switch inst.(type) {
+	case nil:
+		return "null"
+	case Bool:
+		return "boolean"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	}
+	return "object"
+}
+
+func matchesType(t interface{}, inst interface{}) bool {
+	switch t := t.(type) {
+	case String:
+		if t == "integer" {
+			var r = schemaRat(inst)
+			return r != nil && r.IsInt()
+		}
+		return string(t) == schemaType(inst)
+	case Array:
+		for _, elem := range t {
+			if matchesType(elem, inst) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeList(t interface{}) string {
+	if list, ok := t.(Array); ok {
+		var names = make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// schemaRat returns the exact value of a Number, or nil.
+func schemaRat(x interface{}) *big.Rat {
+	if n, ok := x.(Number); ok {
+		if r, err := n.Rat(); err == nil {
+			return r
+		}
+	}
+	return nil
+}
+
+func schemaCount(x interface{}) (int, bool) {
+	if r := schemaRat(x); r != nil && r.IsInt() && r.Num().IsInt64() {
+		return int(r.Num().Int64()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,88 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	src, _ := Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"$ref": "#/$defs/id"},
+			"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"price": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01},
+			"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "uniqueItems": true, "maxItems": 2},
+			"kind": {"oneOf": [{"const": "x"}, {"type": "integer"}]}
+		},
+		"$defs": {"id": {"type": "integer", "minimum": 1}}
+	}`))
+	schema, err := CompileSchema(src)
+	assert.NoError(t, err)
+
+	val, _ := Unmarshal([]byte(`{"id": 1.0, "name": "ab", "price": 0.10, "tags": ["a"], "kind": 3}`))
+	errs, err := schema.Validate(val)
+	assert.NoError(t, err)
+	assert.Equal(t, len(errs), 0)
+
+	val, _ = Unmarshal([]byte(`{"id": 0.5, "name": "A", "price": 0.001, "tags": ["a", "a", "c"], "kind": true, "x": 1}`))
+	errs, err = schema.Validate(val)
+	assert.NoError(t, err)
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, msgs, []string{
+		`$.id: expected integer, got number`,
+		`$.id: 0.5 is less than 1`,
+		`$.kind: value matches 0 of oneOf instead of 1`,
+		`$.name: string does not match "^[a-z]+$"`,
+		`$.price: 0.001 is not a multiple of 0.01`,
+		`$.tags[2]: value is not one of the enum`,
+		`$.tags: array has more than 2 items`,
+		`$.tags: items 0 and 1 are equal`,
+		`$: property "x" is not allowed`,
+	})
+	assert.Equal(t, errs[0].Path, "$.id")
+
+	for _, c := range []struct {
+		schema string
+		doc    string
+		valid  bool
+	}{
+		{`true`, `1`, true},
+		{`false`, `1`, false},
+		{`{"type": ["string", "null"]}`, `null`, true},
+		{`{"not": {"type": "string"}}`, `"a"`, false},
+		{`{"if": {"type": "number"}, "then": {"minimum": 2}, "else": {"const": "x"}}`, `1`, false},
+		{`{"if": {"type": "number"}, "then": {"minimum": 2}, "else": {"const": "x"}}`, `"x"`, true},
+		{`{"contains": {"const": 2}}`, `[1, 2.0]`, true},
+		{`{"items": [{"type": "number"}], "additionalItems": false}`, `[1, 2]`, false},
+		{`{"patternProperties": {"^x": {"type": "number"}}, "additionalProperties": {"type": "string"}}`, `{"x1": 1, "y": "a"}`, true},
+		{`{"propertyNames": {"maxLength": 1}}`, `{"ab": 1}`, false},
+		{`{"minProperties": 1}`, `{}`, false},
+		{`{"maximum": 100000000000000000000000}`, `100000000000000000000001`, false},
+		{`{"items": {"$ref": "#"}, "type": "array"}`, `[[], [1]]`, false},
+	} {
+		src, _ := Unmarshal([]byte(c.schema))
+		schema, err := CompileSchema(src)
+		assert.NoError(t, err, c.schema)
+		val, _ := Unmarshal([]byte(c.doc))
+		errs, err := schema.Validate(val)
+		assert.NoError(t, err, c.schema)
+		assert.Equal(t, len(errs) == 0, c.valid, c.schema+" "+c.doc)
+	}
+
+	for _, s := range []string{`{"$ref": "#/nope"}`, `{"$ref": "http://x"}`, `{"pattern": "("}`, `{"$ref": "#"}`, `{"not": 1}`} {
+		src, _ := Unmarshal([]byte(s))
+		schema, err := CompileSchema(src)
+		assert.NoError(t, err, s)
+		_, err = schema.Validate(Value{value: String("a")})
+		assert.Error(t, err, s)
+	}
+	_, err = CompileSchema(Value{value: Number("1")})
+	assert.Error(t, err)
+}
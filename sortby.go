@@ -0,0 +1,252 @@
+package jsons
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Comparison selects how SortBy compares the values of a SortKey.
+type Comparison int
+
+const (
+	// CompareAuto orders values by type, false, true, numbers, strings,
+	// arrays then objects, and within a type by value.
+	CompareAuto Comparison = iota
+	// CompareNumeric compares Numbers, and Strings holding numbers, by
+	// exact decimal value.
+	CompareNumeric
+	// CompareString compares Strings, and the JSON of other values, by
+	// bytes.
+	CompareString
+	// CompareNatural compares like CompareString, but runs of digits by
+	// their numeric value, so "file2" sorts before "file10".
+	CompareNatural
+	// CompareTime compares times as parsed by Value.Time.
+	CompareTime
+)
+
+// SortKey is one key of SortBy: the value at Path within each element,
+// compared as Compare says. Values that are null, missing or not of the
+// compared kind sort last, or first with NullsFirst, in either direction.
+type SortKey struct {
+	Path       []interface{}
+	Descending bool
+	Compare    Comparison
+	NullsFirst bool
+	// Less, if set, replaces Compare.
+	Less func(a, b Value) bool
+}
+
+// Asc sorts by the value at keys in ascending order.
+func Asc(keys ...interface{}) SortKey {
+	return SortKey{Path: keys}
+}
+
+// Desc sorts by the value at keys in descending order.
+func Desc(keys ...interface{}) SortKey {
+	return SortKey{Path: keys, Descending: true}
+}
+
+// By returns k comparing values as c says.
+func (k SortKey) By(c Comparison) SortKey {
+	k.Compare = c
+	return k
+}
+
+// Nulls returns k sorting null values first or last.
+func (k SortKey) Nulls(first bool) SortKey {
+	k.NullsFirst = first
+	return k
+}
+
+// sortCell is the value of a key for one element, parsed once.
+type sortCell struct {
+	val  Value
+	null bool
+	num  *big.Rat
+	time time.Time
+	str  string
+	rank int
+}
+
+func (k SortKey) cell(elem interface{}) sortCell {
+	var c = sortCell{val: value(elem).Get(k.Path...)}
+	src, err := normalize(c.val.value)
+	if err != nil || src == nil {
+		c.null = true
+		return c
+	}
+	c.val = Value{value: src}
+	if k.Less != nil {
+		return c
+	}
+	switch k.Compare {
+	case CompareNumeric:
+		var s string
+		switch v := src.(type) {
+		case Number:
+			s = string(v)
+		case String:
+			s = strings.TrimSpace(string(v))
+		}
+		if c.num, err = Number(s).Rat(); err != nil {
+			c.null = true
+		}
+	case CompareTime:
+		if c.time, err = c.val.parseTime(); err != nil {
+			c.null = true
+		}
+	case CompareString, CompareNatural:
+		if s, ok := src.(String); ok {
+			c.str = string(s)
+		} else {
+			c.str = string(canonicalKey(c.val))
+		}
+	default:
+		switch v := src.(type) {
+		case Bool:
+			if v {
+				c.rank = 1
+			}
+		case Number:
+			c.rank = 2
+			if c.num, err = v.Rat(); err != nil {
+				c.str = string(v)
+			}
+		case String:
+			c.rank, c.str = 3, string(v)
+		case Array:
+			c.rank, c.str = 4, string(canonicalKey(c.val))
+		case Object:
+			c.rank, c.str = 5, string(canonicalKey(c.val))
+		}
+	}
+	return c
+}
+
+// compare returns the order of a and b ignoring direction and nulls.
+func (k SortKey) compare(a, b sortCell) int {
+	switch {
+	case k.Less != nil:
+		if k.Less(a.val, b.val) {
+			return -1
+		}
+		if k.Less(b.val, a.val) {
+			return 1
+		}
+		return 0
+	case k.Compare == CompareNumeric:
+		return a.num.Cmp(b.num)
+	case k.Compare == CompareTime:
+		switch {
+		case a.time.Before(b.time):
+			return -1
+		case a.time.After(b.time):
+			return 1
+		}
+		return 0
+	case k.Compare == CompareNatural:
+		return naturalCompare(a.str, b.str)
+	case k.Compare == CompareString:
+		return strings.Compare(a.str, b.str)
+	}
+	switch {
+	case a.rank != b.rank:
+		return a.rank - b.rank
+	case a.num != nil && b.num != nil:
+		return a.num.Cmp(b.num)
+	}
+	return strings.Compare(a.str, b.str)
+}
+
+// naturalCompare compares runs of digits by value and other text by bytes.
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		var da, db = digitRun(a), digitRun(b)
+		if da > 0 && db > 0 {
+			var na, nb = strings.TrimLeft(a[:da], "0"), strings.TrimLeft(b[:db], "0")
+			if len(na) != len(nb) {
+				return len(na) - len(nb)
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func digitRun(s string) int {
+	var i int
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// SortBy sorts the Array in place by the given keys, each breaking the ties
+// of the ones before it. The sort is stable, and with no keys the elements
+// themselves are compared with CompareAuto.
+func (a Array) SortBy(spec ...SortKey) Array {
+	if len(spec) == 0 {
+		spec = []SortKey{{}}
+	}
+	var cells = make([][]sortCell, len(a))
+	for i, elem := range a {
+		cells[i] = make([]sortCell, len(spec))
+		for j, k := range spec {
+			cells[i][j] = k.cell(elem)
+		}
+	}
+	var order = make([]int, len(a))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		var ci, cj = cells[order[i]], cells[order[j]]
+		for n, k := range spec {
+			var x, y = ci[n], cj[n]
+			if x.null || y.null {
+				if x.null == y.null {
+					continue
+				}
+				return x.null == k.NullsFirst
+			}
+			c := k.compare(x, y)
+			if k.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	var sorted = make(Array, len(a))
+	for i, idx := range order {
+		sorted[i] = a[idx]
+	}
+	copy(a, sorted)
+	return a
+}
+
+// Sorted returns a sorted copy of the Array, leaving it unchanged.
+func (a Array) Sorted(spec ...SortKey) Array {
+	return append(make(Array, 0, len(a)), a...).SortBy(spec...)
+}
+
+func (v Value) SortBy(spec ...SortKey) Array {
+	return v.Array().SortBy(spec...)
+}
+
+func (v Value) Sorted(spec ...SortKey) Array {
+	return v.Array().Sorted(spec...)
+}
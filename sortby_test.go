@@ -0,0 +1,51 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestArray_SortBy(t *testing.T) {
+	val, _ := Unmarshal([]byte(`[
+		{"name": "file10", "age": 30, "joined": "2021-03-01T00:00:00Z"},
+		{"name": "file2", "age": "9", "joined": "2020-01-01T00:00:00Z"},
+		{"name": "File1", "age": 30.0, "joined": null},
+		{"name": "file3", "joined": "2022-12-31T00:00:00Z"},
+		{"name": "file2", "age": 123456789012345678901234567890}
+	]`))
+	names := func(arr Array) string {
+		return Value{value: arr.Map(func(index int, val Value) interface{} {
+			return val.String("name")
+		})}.JSONString()
+	}
+
+	arr := val.Array()
+	assert.Equal(t, names(arr.Sorted(Asc("name"))), `["File1","file10","file2","file2","file3"]`)
+	assert.Equal(t, names(arr.Sorted(Asc("name").By(CompareNatural))), `["File1","file2","file2","file3","file10"]`)
+	assert.Equal(t, names(arr), `["file10","file2","File1","file3","file2"]`)
+
+	// Stable within equal ages, with the missing age last either way.
+	assert.Equal(t, names(arr.Sorted(Asc("age").By(CompareNumeric))), `["file2","file10","File1","file2","file3"]`)
+	assert.Equal(t, names(arr.Sorted(Desc("age").By(CompareNumeric))), `["file2","file10","File1","file2","file3"]`)
+	assert.Equal(t, names(arr.Sorted(Desc("age").By(CompareNumeric).Nulls(true))), `["file3","file2","file10","File1","file2"]`)
+
+	assert.Equal(t, names(arr.Sorted(Asc("age").By(CompareNumeric), Desc("name"))), `["file2","file10","File1","file2","file3"]`)
+	assert.Equal(t, names(arr.Sorted(Asc("joined").By(CompareTime))), `["file2","file10","file3","File1","file2"]`)
+	assert.Equal(t, names(arr.Sorted(SortKey{Path: []interface{}{"name"}, Less: func(a, b Value) bool {
+		return len(a.String()) < len(b.String())
+	}})), `["file2","File1","file3","file2","file10"]`)
+
+	// CompareAuto orders by type first.
+	mixed := Array{"b", 2, nil, true, Object{}, "a", 10, false, Array{}}
+	assert.Equal(t, Value{value: mixed.SortBy()}.JSONString(), `[false,true,2,10,"a","b",[],{},null]`)
+	assert.Equal(t, Value{value: val.SortBy(Asc("name"))}.Len(), 5)
+	assert.Equal(t, val.String(0, "name"), "File1")
+}
+
+func TestNaturalCompare(t *testing.T) {
+	assert.True(t, naturalCompare("a2", "a10") < 0)
+	assert.True(t, naturalCompare("a02", "a2") == 0)
+	assert.True(t, naturalCompare("a2b", "a2a") > 0)
+	assert.True(t, naturalCompare("a", "a1") < 0)
+}
@@ -0,0 +1,265 @@
+package jsons
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the layout used to format times written by SetTime, and the
+// first layout tried when parsing.
+var TimeLayout = time.RFC3339Nano
+
+// TimeLayouts are the additional layouts tried, in order, when parsing a time
+// string. Custom layouts can be appended.
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// DurationUnit is the unit of a duration given as a Number.
+var DurationUnit = time.Second
+
+// unixMillisThreshold separates unix seconds from unix milliseconds: 1e11
+// seconds is in the year 5138, 1e11 milliseconds is in 1973.
+const unixMillisThreshold = 1e11
+
+// ParseTime parses s with the given layouts, falling back to TimeLayout,
+// TimeLayouts and unix seconds/milliseconds.
+func ParseTime(s string, layouts ...string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layouts := range [][]string{layouts, {TimeLayout}, TimeLayouts} {
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	}
+	if t, err := unixTime(Number(s)); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("jsons: cannot parse %q as time", s)
+}
+
+func unixTime(n Number) (time.Time, error) {
+	if i, err := n.Int64(); err == nil {
+		if i >= unixMillisThreshold || i <= -unixMillisThreshold {
+			return time.Unix(i/1e3, i%1e3*1e6), nil
+		}
+		return time.Unix(i, 0), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return time.Time{}, errors.New("jsons: invalid unix time")
+	}
+	if math.Abs(f) >= unixMillisThreshold {
+		f /= 1e3
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+// ParseDuration parses an ISO-8601 duration ("PT1H30M", "P2DT3S") or a Go
+// duration ("1h30m").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	return parseISODuration(s)
+}
+
+func parseISODuration(s string) (time.Duration, error) {
+	var invalid = fmt.Errorf("jsons: cannot parse %q as duration", s)
+	var str = s
+	var sign = time.Duration(1)
+	switch {
+	case strings.HasPrefix(str, "-"):
+		sign, str = -1, str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+	if len(str) < 2 || (str[0] != 'P' && str[0] != 'p') {
+		return 0, invalid
+	}
+	str = str[1:]
+
+	var total float64
+	var inTime bool
+	for len(str) > 0 {
+		if str[0] == 'T' || str[0] == 't' {
+			if inTime || len(str) == 1 {
+				return 0, invalid
+			}
+			inTime, str = true, str[1:]
+			continue
+		}
+		var i = 0
+		for i < len(str) && (str[i] >= '0' && str[i] <= '9' || str[i] == '.' || str[i] == ',') {
+			i++
+		}
+		if i == 0 || i == len(str) {
+			return 0, invalid
+		}
+		num, err := strconv.ParseFloat(strings.Replace(str[:i], ",", ".", 1), 64)
+		if err != nil {
+			return 0, invalid
+		}
+		var unit time.Duration
+		switch c := str[i]; {
+		case !inTime && (c == 'W' || c == 'w'):
+			unit = 7 * 24 * time.Hour
+		case !inTime && (c == 'D' || c == 'd'):
+			unit = 24 * time.Hour
+		case !inTime && (c == 'Y' || c == 'y' || c == 'M' || c == 'm'):
+			return 0, fmt.Errorf("jsons: ambiguous duration %q: years and months are not supported", s)
+		case inTime && (c == 'H' || c == 'h'):
+			unit = time.Hour
+		case inTime && (c == 'M' || c == 'm'):
+			unit = time.Minute
+		case inTime && (c == 'S' || c == 's'):
+			unit = time.Second
+		default:
+			return 0, invalid
+		}
+		total += num * float64(unit)
+		str = str[i+1:]
+	}
+	if total >= math.MaxInt64 {
+		return 0, fmt.Errorf("jsons: duration %q overflows", s)
+	}
+
+	return sign * time.Duration(total), nil
+}
+
+// FormatDuration formats d as an ISO-8601 duration, e.g. "PT1H30M0.5S".
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var buf strings.Builder
+	var u = uint64(d)
+	if d < 0 {
+		buf.WriteByte('-')
+		u = -u
+	}
+	buf.WriteString("PT")
+	if h := u / uint64(time.Hour); h > 0 {
+		buf.WriteString(strconv.FormatUint(h, 10))
+		buf.WriteByte('H')
+		u -= h * uint64(time.Hour)
+	}
+	if m := u / uint64(time.Minute); m > 0 {
+		buf.WriteString(strconv.FormatUint(m, 10))
+		buf.WriteByte('M')
+		u -= m * uint64(time.Minute)
+	}
+	if u > 0 {
+		sec := strconv.FormatUint(u/uint64(time.Second), 10)
+		if ns := u % uint64(time.Second); ns > 0 {
+			frac := strings.TrimRight(fmt.Sprintf("%09d", ns), "0")
+			sec += "." + frac
+		}
+		buf.WriteString(sec)
+		buf.WriteByte('S')
+	}
+	return buf.String()
+}
+
+func (v Value) parseTime() (time.Time, error) {
+	switch {
+	case v.IsNumber():
+		return unixTime(v.Number())
+	case v.IsString():
+		return ParseTime(v.String())
+	}
+	return time.Time{}, fmt.Errorf("jsons: cannot use %s as time", v.Type())
+}
+
+func (v Value) parseDuration() (time.Duration, error) {
+	switch {
+	case v.IsNumber():
+		f, err := v.Number().Float64()
+		if err != nil {
+			return 0, err
+		}
+		if d := f * float64(DurationUnit); math.Abs(d) < math.MaxInt64 {
+			return time.Duration(d), nil
+		}
+		return 0, fmt.Errorf("jsons: duration %s overflows", v.Number())
+	case v.IsString():
+		return ParseDuration(v.String())
+	}
+	return 0, fmt.Errorf("jsons: cannot use %s as duration", v.Type())
+}
+
+func (v Value) Time(keys ...interface{}) time.Time {
+	t, _ := v.Get(keys...).parseTime()
+	return t
+}
+
+func (v Value) Duration(keys ...interface{}) time.Duration {
+	d, _ := v.Get(keys...).parseDuration()
+	return d
+}
+
+// SetTime is Set with a time.Time last argument, formatted with TimeLayout.
+func (v Value) SetTime(keys ...interface{}) {
+	v.Set(timeArgs(keys)...)
+}
+
+// SetDuration is Set with a time.Duration last argument, formatted as an
+// ISO-8601 duration.
+func (v Value) SetDuration(keys ...interface{}) {
+	v.Set(timeArgs(keys)...)
+}
+
+// timeArgs formats the time or duration at the end of the arguments of Set.
+func timeArgs(keys []interface{}) []interface{} {
+	if len(keys) == 0 {
+		return keys
+	}
+	var args = append(make([]interface{}, 0, len(keys)), keys...)
+	switch last := args[len(args)-1].(type) {
+	case time.Time:
+		args[len(args)-1] = last.Format(TimeLayout)
+	case time.Duration:
+		args[len(args)-1] = FormatDuration(last)
+	}
+	return args
+}
+
+func (o Object) Time(keys ...interface{}) time.Time {
+	return o.Get(keys...).Time()
+}
+
+func (o Object) Duration(keys ...interface{}) time.Duration {
+	return o.Get(keys...).Duration()
+}
+
+func (o Object) SetTime(keys ...interface{}) {
+	o.Set(timeArgs(keys)...)
+}
+
+func (o Object) SetDuration(keys ...interface{}) {
+	o.Set(timeArgs(keys)...)
+}
+
+func (r Raw) Time(keys ...interface{}) time.Time {
+	return r.JSONValue(keys...).Time()
+}
+
+func (r Raw) Duration(keys ...interface{}) time.Duration {
+	return r.JSONValue(keys...).Duration()
+}
@@ -0,0 +1,94 @@
+package jsons
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestValue_Time(t *testing.T) {
+	val, err := Unmarshal([]byte(`{
+		"rfc3339": "2022-05-01T10:20:30Z",
+		"nano": "2022-05-01T10:20:30.123456789+08:00",
+		"seconds": 1651400430,
+		"millis": 1651400430123,
+		"fraction": 1651400430.5,
+		"custom": "01/05/2022 10:20",
+		"bad": true
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2022, 5, 1, 10, 20, 30, 0, time.UTC)
+	assert.True(t, val.Time("rfc3339").Equal(base))
+	assert.True(t, val.Time("nano").Equal(base.Add(-8*time.Hour+123456789)))
+	assert.True(t, val.Time("seconds").Equal(base))
+	assert.True(t, val.Time("millis").Equal(base.Add(123*time.Millisecond)))
+	assert.True(t, val.Time("fraction").Equal(base.Add(500*time.Millisecond)))
+	assert.True(t, val.Time("bad").IsZero())
+	assert.True(t, val.Time("not exist").IsZero())
+	assert.True(t, val.Time("custom").IsZero())
+
+	custom, err := ParseTime(val.String("custom"), "02/01/2006 15:04")
+	assert.NoError(t, err)
+	assert.True(t, custom.Equal(base.Add(-30*time.Second)))
+
+	layouts := TimeLayouts
+	TimeLayouts = append(TimeLayouts, "02/01/2006 15:04")
+	assert.True(t, val.Time("custom").Equal(base.Add(-30*time.Second)))
+	TimeLayouts = layouts
+
+	val.SetTime("rfc3339", base)
+	assert.Equal(t, val.String("rfc3339"), "2022-05-01T10:20:30Z")
+	val.Set("set", base.Add(time.Millisecond))
+	assert.Equal(t, val.String("set"), "2022-05-01T10:20:30.001Z")
+}
+
+func TestValue_Duration(t *testing.T) {
+	val, err := Unmarshal([]byte(`{
+		"iso": "PT1H30M",
+		"days": "P1DT0.5S",
+		"week": "P1W",
+		"negative": "-PT10S",
+		"go": "1h2m3s",
+		"seconds": 90,
+		"fraction": 1.5,
+		"ambiguous": "P1M"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, val.Duration("iso"), 90*time.Minute)
+	assert.Equal(t, val.Duration("days"), 24*time.Hour+500*time.Millisecond)
+	assert.Equal(t, val.Duration("week"), 7*24*time.Hour)
+	assert.Equal(t, val.Duration("negative"), -10*time.Second)
+	assert.Equal(t, val.Duration("go"), time.Hour+2*time.Minute+3*time.Second)
+	assert.Equal(t, val.Duration("seconds"), 90*time.Second)
+	assert.Equal(t, val.Duration("fraction"), 1500*time.Millisecond)
+	assert.Equal(t, val.Duration("ambiguous"), time.Duration(0))
+
+	_, err = ParseDuration("P1M")
+	assert.Error(t, err)
+	_, err = ParseDuration("PT")
+	assert.Error(t, err)
+
+	assert.Equal(t, FormatDuration(0), "PT0S")
+	assert.Equal(t, FormatDuration(90*time.Minute), "PT1H30M")
+	assert.Equal(t, FormatDuration(-1500*time.Millisecond), "-PT1.5S")
+	assert.Equal(t, FormatDuration(25*time.Hour+time.Nanosecond), "PT25H0.000000001S")
+
+	val.SetDuration("iso", time.Minute+time.Second)
+	assert.Equal(t, val.String("iso"), "PT1M1S")
+	assert.Equal(t, val.Duration("iso"), time.Minute+time.Second)
+
+	for _, s := range []string{"P300Y", "P200000W", "PT2562048H", "-P106752D"} {
+		_, err = ParseDuration(s)
+		assert.Error(t, err, s)
+	}
+	big, _ := Unmarshal([]byte(`{"s": 1e12, "neg": -1e12}`))
+	assert.Equal(t, big.Duration("s"), time.Duration(0))
+	assert.Equal(t, big.Duration("neg"), time.Duration(0))
+}
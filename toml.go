@@ -0,0 +1,221 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLOptions controls how TOML datetimes are written into a Value. Empty
+// layouts fall back to TimeLayout for offset datetimes and to the RFC 3339
+// forms TOML itself uses for local datetimes, dates and times.
+type TOMLOptions struct {
+	DatetimeLayout      string
+	LocalDatetimeLayout string
+	LocalDateLayout     string
+	LocalTimeLayout     string
+}
+
+func (o TOMLOptions) format(t time.Time) string {
+	var layout string
+	switch t.Location().String() {
+	case "datetime-local":
+		layout = o.LocalDatetimeLayout
+		if layout == "" {
+			layout = "2006-01-02T15:04:05.999999999"
+		}
+	case "date-local":
+		layout = o.LocalDateLayout
+		if layout == "" {
+			layout = "2006-01-02"
+		}
+	case "time-local":
+		layout = o.LocalTimeLayout
+		if layout == "" {
+			layout = "15:04:05.999999999"
+		}
+	default:
+		layout = o.DatetimeLayout
+		if layout == "" {
+			layout = TimeLayout
+		}
+	}
+	return t.Format(layout)
+}
+
+// FromTOML converts a TOML document to a Value with default TOMLOptions.
+func FromTOML(data []byte) (Value, error) {
+	return FromTOMLWithOptions(data, TOMLOptions{})
+}
+
+// FromTOMLWithOptions converts a TOML document to a Value: tables become
+// Objects, arrays of tables Arrays of Objects and datetimes Strings formatted
+// as opts says. TOML's inf and nan have no JSON form and are an error.
+func FromTOMLWithOptions(data []byte, opts TOMLOptions) (Value, error) {
+	var doc map[string]interface{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return Value{}, err
+	}
+	val, err := fromTOML(doc, opts, nil)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{value: val}, nil
+}
+
+func fromTOML(src interface{}, opts TOMLOptions, path []interface{}) (interface{}, error) {
+	switch v := src.(type) {
+	case map[string]interface{}:
+		var obj = make(map[string]interface{}, len(v))
+		for key, val := range v {
+			elem, err := fromTOML(val, opts, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = elem
+		}
+		return obj, nil
+	case []map[string]interface{}:
+		var arr = make([]interface{}, len(v))
+		for i, val := range v {
+			elem, err := fromTOML(val, opts, append(path, i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = elem
+		}
+		return arr, nil
+	case []interface{}:
+		var arr = make([]interface{}, len(v))
+		for i, val := range v {
+			elem, err := fromTOML(val, opts, append(path, i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = elem
+		}
+		return arr, nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("jsons: toml: %v at %s is not a valid JSON number", v, JSONPath(path...))
+		}
+		return json.Number(formatFloat(v)), nil
+	case time.Time:
+		return opts.format(v), nil
+	}
+	return src, nil
+}
+
+// formatFloat keeps a fraction on whole floats so they stay floats when
+// converted back. Infinities and NaN are spelled as Extended JSON's
+// $numberDouble spells them; callers that produce Numbers reject them first.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	var s = strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEn") {
+		s += ".0"
+	}
+	return s
+}
+
+// TOML encodes the Object at keys as a TOML document. Arrays holding only
+// Objects become arrays of tables; null has no TOML form and is an error.
+func (v Value) TOML(keys ...interface{}) ([]byte, error) {
+	root, err := normalize(v.Get(keys...).value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := root.(Object); !ok {
+		return nil, fmt.Errorf("jsons: toml: document root must be an object, not %s", typeName(root))
+	}
+	src, err := toTOML(root, nil)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err = enc.Encode(src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toTOML(src interface{}, path []interface{}) (interface{}, error) {
+	src, err := normalize(src)
+	if err != nil {
+		return nil, err
+	}
+	switch v := src.(type) {
+	case nil:
+		return nil, fmt.Errorf("jsons: toml: cannot encode null at %s", JSONPath(path...))
+	case Bool:
+		return bool(v), nil
+	case Number:
+		return tomlNumber(v, path)
+	case String:
+		return string(v), nil
+	case Array:
+		var arr = make([]interface{}, len(v))
+		var tables = make([]map[string]interface{}, 0, len(v))
+		for i, elem := range v {
+			val, err := toTOML(elem, append(path, i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = val
+			if table, ok := val.(map[string]interface{}); ok {
+				tables = append(tables, table)
+			}
+		}
+		if len(v) > 0 && len(tables) == len(v) {
+			return tables, nil
+		}
+		return arr, nil
+	case Object:
+		var obj = make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			val, err := toTOML(elem, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = val
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("jsons: toml: cannot encode %T at %s", src, JSONPath(path...))
+}
+
+func tomlNumber(n Number, path []interface{}) (interface{}, error) {
+	if n == "" {
+		return int64(0), nil
+	}
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("jsons: toml: invalid number %q at %s", string(n), JSONPath(path...))
+	}
+	if !strings.ContainsAny(string(n), ".eE") {
+		return nil, fmt.Errorf("jsons: toml: integer %s at %s overflows int64", string(n), JSONPath(path...))
+	}
+	return f, nil
+}
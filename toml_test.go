@@ -0,0 +1,80 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestFromTOML(t *testing.T) {
+	var data = []byte(`
+title = "service"
+ratio = 2.0
+when = 2022-05-01T10:20:30Z
+day = 2022-05-01
+at = 07:32:00
+local = 2022-05-01T07:32:00.5
+
+[db]
+host = "localhost"
+ports = [5432, 5433]
+
+[[backends]]
+name = "a"
+
+[[backends]]
+name = "b"
+weight = 3
+`)
+	val, err := FromTOML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, val.String("title"), "service")
+	assert.Equal(t, val.Number("ratio"), Number("2.0"))
+	assert.Equal(t, val.String("when"), "2022-05-01T10:20:30Z")
+	assert.Equal(t, val.String("day"), "2022-05-01")
+	assert.Equal(t, val.String("at"), "07:32:00")
+	assert.Equal(t, val.String("local"), "2022-05-01T07:32:00.5")
+	assert.Equal(t, val.Int("db", "ports", 1), int64(5433))
+	assert.Equal(t, val.Len("backends"), 2)
+	assert.Equal(t, val.Int("backends", 1, "weight"), int64(3))
+
+	val, err = FromTOMLWithOptions(data, TOMLOptions{DatetimeLayout: "2006/01/02 15:04", LocalDateLayout: "02.01.2006"})
+	assert.NoError(t, err)
+	assert.Equal(t, val.String("when"), "2022/05/01 10:20")
+	assert.Equal(t, val.String("day"), "01.05.2022")
+
+	_, err = FromTOML([]byte("a = "))
+	assert.Error(t, err)
+
+	_, err = FromTOML([]byte("[t]\na = [1.0, -inf]"))
+	assert.EqualError(t, err, "jsons: toml: -Inf at $.t.a[1] is not a valid JSON number")
+	_, err = FromTOML([]byte("a = nan"))
+	assert.Error(t, err)
+}
+
+func TestValue_TOML(t *testing.T) {
+	val, err := Unmarshal([]byte(`{"title": "x", "ratio": 2.0, "db": {"port": 5432}, "backends": [{"name": "a"}, {"name": "b"}], "mixed": [1, "a"]}`))
+	assert.NoError(t, err)
+	data, err := val.TOML()
+	assert.NoError(t, err)
+
+	back, err := FromTOML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), val.JSONString())
+
+	_, err = val.TOML("title")
+	assert.Error(t, err)
+
+	val.Set("db", "user", nil)
+	_, err = val.TOML()
+	assert.EqualError(t, err, "jsons: toml: cannot encode null at $.db.user")
+
+	val, _ = Unmarshal([]byte(`{"big": 123456789012345678901234567890}`))
+	_, err = val.TOML()
+	assert.Error(t, err)
+
+	for _, n := range []Number{"Infinity", "-Infinity", "NaN", "inf", "1e400"} {
+		_, err = Value{value: Object{"a": n}}.TOML()
+		assert.Error(t, err, string(n))
+	}
+}
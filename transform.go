@@ -0,0 +1,238 @@
+package jsons
+
+import (
+	"bytes"
+)
+
+// The higher-order helpers below work on the Array at keys, like Reverse,
+// and return new Arrays without changing the original. GroupBy, KeyBy and
+// Uniq compare the value at field within each element, the whole element
+// if field is empty.
+
+func (a Array) Map(fn func(index int, val Value) interface{}, keys ...interface{}) Array {
+	var src = a.Get(keys...).Array()
+	var dst = make(Array, len(src))
+	for i, elem := range src {
+		dst[i] = fn(i, value(elem))
+	}
+	return dst
+}
+
+func (a Array) Filter(fn func(index int, val Value) bool, keys ...interface{}) Array {
+	var dst = make(Array, 0)
+	for i, elem := range a.Get(keys...).Array() {
+		if fn(i, value(elem)) {
+			dst = append(dst, elem)
+		}
+	}
+	return dst
+}
+
+// Reduce folds the Array into a value, starting from initial.
+func (a Array) Reduce(fn func(acc Value, index int, val Value) interface{}, initial interface{}, keys ...interface{}) Value {
+	var acc = value(initial)
+	for i, elem := range a.Get(keys...).Array() {
+		acc = value(fn(acc, i, value(elem)))
+	}
+	return acc
+}
+
+// Find returns the first element fn accepts, or null.
+func (a Array) Find(fn func(index int, val Value) bool, keys ...interface{}) Value {
+	if i := a.FindIndex(fn, keys...); i >= 0 {
+		return a.Get(keys...).Get(i)
+	}
+	return Value{}
+}
+
+// FindIndex returns the index of the first element fn accepts, or -1.
+func (a Array) FindIndex(fn func(index int, val Value) bool, keys ...interface{}) int {
+	for i, elem := range a.Get(keys...).Array() {
+		if fn(i, value(elem)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Every reports whether fn accepts every element; it is true for an empty
+// Array.
+func (a Array) Every(fn func(index int, val Value) bool, keys ...interface{}) bool {
+	return a.FindIndex(func(index int, val Value) bool {
+		return !fn(index, val)
+	}, keys...) < 0
+}
+
+// Some reports whether fn accepts any element.
+func (a Array) Some(fn func(index int, val Value) bool, keys ...interface{}) bool {
+	return a.FindIndex(fn, keys...) >= 0
+}
+
+// GroupBy collects the elements into Arrays by the value at field within
+// each element. Strings group under themselves and other values under
+// their JSON, such as "1", "true" or "null".
+func (a Array) GroupBy(field []interface{}, keys ...interface{}) Object {
+	var groups = make(Object)
+	for _, elem := range a.Get(keys...).Array() {
+		var key = groupKey(value(elem).Get(field...))
+		groups[key] = append(value(groups[key]).Array(), elem)
+	}
+	return groups
+}
+
+// KeyBy maps the value at field within each element to the element, as
+// GroupBy names them; later elements win.
+func (a Array) KeyBy(field []interface{}, keys ...interface{}) Object {
+	var obj = make(Object)
+	for _, elem := range a.Get(keys...).Array() {
+		obj[groupKey(value(elem).Get(field...))] = elem
+	}
+	return obj
+}
+
+// Uniq keeps the first element for every distinct value at field within the
+// elements, comparing values by their canonical JSON so that 1 and 1.0 are
+// equal.
+func (a Array) Uniq(field []interface{}, keys ...interface{}) Array {
+	var seen = make(map[string]bool)
+	var dst = make(Array, 0)
+	for _, elem := range a.Get(keys...).Array() {
+		var key = string(canonicalKey(value(elem).Get(field...)))
+		if !seen[key] {
+			seen[key] = true
+			dst = append(dst, elem)
+		}
+	}
+	return dst
+}
+
+// Chunk splits the Array into Arrays of size elements, the last of which may
+// be shorter. It returns nil if size is not positive.
+func (a Array) Chunk(size int, keys ...interface{}) Array {
+	if size <= 0 {
+		return nil
+	}
+	var src = a.Get(keys...).Array()
+	var dst = make(Array, 0, (len(src)+size-1)/size)
+	for len(src) > size {
+		dst = append(dst, append(Array{}, src[:size]...))
+		src = src[size:]
+	}
+	if len(src) > 0 {
+		dst = append(dst, append(Array{}, src...))
+	}
+	return dst
+}
+
+// Zip pairs up the elements of the Array and others by index, padding the
+// shorter ones with null: [1, 2] zipped with ["a"] is [[1, "a"], [2, null]].
+// Unlike the other helpers it takes no keys, its variadic arguments being the
+// Arrays to zip; select nested Arrays with Array(keys...) first.
+func (a Array) Zip(others ...Array) Array {
+	var arrays = append([]Array{a}, others...)
+	var n int
+	for _, arr := range arrays {
+		if len(arr) > n {
+			n = len(arr)
+		}
+	}
+	var dst = make(Array, n)
+	for i := range dst {
+		var tuple = make(Array, len(arrays))
+		for j, arr := range arrays {
+			if i < len(arr) {
+				tuple[j] = arr[i]
+			}
+		}
+		dst[i] = tuple
+	}
+	return dst
+}
+
+// FlattenArray splices nested Arrays into the Array, down to depth levels; a
+// negative depth flattens completely.
+func (a Array) FlattenArray(depth int, keys ...interface{}) Array {
+	return flattenArray(make(Array, 0), a.Get(keys...).Array(), depth)
+}
+
+func flattenArray(dst, src Array, depth int) Array {
+	for _, elem := range src {
+		if val := value(elem); depth != 0 && val.IsArray() {
+			dst = flattenArray(dst, val.Array(), depth-1)
+		} else {
+			dst = append(dst, elem)
+		}
+	}
+	return dst
+}
+
+func groupKey(val Value) string {
+	if val.IsString() {
+		return val.String()
+	}
+	return string(canonicalKey(val))
+}
+
+func canonicalKey(val Value) []byte {
+	var buf bytes.Buffer
+	if err := canonical(&buf, val.value); err != nil {
+		return val.JSON()
+	}
+	return buf.Bytes()
+}
+
+func (v Value) Map(fn func(index int, val Value) interface{}, keys ...interface{}) Array {
+	return v.Array(keys...).Map(fn)
+}
+
+func (v Value) Filter(fn func(index int, val Value) bool, keys ...interface{}) Array {
+	return v.Array(keys...).Filter(fn)
+}
+
+func (v Value) Reduce(fn func(acc Value, index int, val Value) interface{}, initial interface{}, keys ...interface{}) Value {
+	return v.Array(keys...).Reduce(fn, initial)
+}
+
+func (v Value) Find(fn func(index int, val Value) bool, keys ...interface{}) Value {
+	return v.Array(keys...).Find(fn)
+}
+
+func (v Value) FindIndex(fn func(index int, val Value) bool, keys ...interface{}) int {
+	return v.Array(keys...).FindIndex(fn)
+}
+
+func (v Value) Every(fn func(index int, val Value) bool, keys ...interface{}) bool {
+	return v.Array(keys...).Every(fn)
+}
+
+func (v Value) Some(fn func(index int, val Value) bool, keys ...interface{}) bool {
+	return v.Array(keys...).Some(fn)
+}
+
+func (v Value) GroupBy(field []interface{}, keys ...interface{}) Object {
+	return v.Array(keys...).GroupBy(field)
+}
+
+func (v Value) KeyBy(field []interface{}, keys ...interface{}) Object {
+	return v.Array(keys...).KeyBy(field)
+}
+
+func (v Value) Uniq(field []interface{}, keys ...interface{}) Array {
+	return v.Array(keys...).Uniq(field)
+}
+
+func (v Value) Chunk(size int, keys ...interface{}) Array {
+	return v.Array(keys...).Chunk(size)
+}
+
+// Zip zips the Value's Array with others like Array.Zip, and likewise takes
+// no keys.
+func (v Value) Zip(others ...Array) Array {
+	return v.Array().Zip(others...)
+}
+
+// FlattenArray splices nested Arrays into the Array at keys like
+// Array.FlattenArray, where Value.Flatten flattens into paths instead.
+func (v Value) FlattenArray(depth int, keys ...interface{}) Array {
+	return v.Array(keys...).FlattenArray(depth)
+}
@@ -0,0 +1,63 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestArray_Transform(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"users": [
+		{"name": "ann", "age": 31, "team": "a"},
+		{"name": "bob", "age": 25, "team": "b"},
+		{"name": "cat", "age": 31.0, "team": "a"},
+		{"name": "dan", "team": null}
+	]}`))
+
+	names := val.Map(func(index int, val Value) interface{} {
+		return val.String("name")
+	}, "users")
+	assert.Equal(t, Value{value: names}.JSONString(), `["ann","bob","cat","dan"]`)
+
+	adults := val.Filter(func(index int, val Value) bool {
+		return val.Float("age") > 30
+	}, "users")
+	assert.Equal(t, len(adults), 2)
+	assert.Equal(t, Value{value: val.Filter(func(int, Value) bool { return false }, "users")}.JSONString(), `[]`)
+
+	total := val.Reduce(func(acc Value, index int, val Value) interface{} {
+		return acc.Float() + val.Float("age")
+	}, 0, "users")
+	assert.Equal(t, total.Float(), 87.0)
+
+	isBob := func(index int, val Value) bool { return val.String("name") == "bob" }
+	assert.Equal(t, val.Find(isBob, "users").Int("age"), int64(25))
+	assert.Equal(t, val.FindIndex(isBob, "users"), 1)
+	assert.Equal(t, val.Find(isBob, "missing").IsNull(), true)
+	assert.Equal(t, val.FindIndex(isBob, "missing"), -1)
+	assert.True(t, val.Some(isBob, "users"))
+	assert.False(t, val.Every(isBob, "users"))
+	assert.True(t, val.Every(isBob, "missing"))
+
+	users := val.Array("users")
+	assert.Equal(t, Value{value: val.GroupBy([]interface{}{"team"}, "users")}.JSONString(),
+		`{"a":[{"age":31,"name":"ann","team":"a"},{"age":31.0,"name":"cat","team":"a"}],"b":[{"age":25,"name":"bob","team":"b"}],"null":[{"name":"dan","team":null}]}`)
+	assert.Equal(t, users.KeyBy([]interface{}{"name"}).Get("bob", "age").Int(), int64(25))
+	assert.Equal(t, Value{value: val.KeyBy([]interface{}{"name"}, "missing")}.JSONString(), `{}`)
+	assert.Equal(t, Value{value: users.Uniq([]interface{}{"age"}).Map(func(index int, val Value) interface{} {
+		return val.String("name")
+	})}.JSONString(), `["ann","bob","dan"]`)
+	assert.Equal(t, Value{value: Array{1, 1.0, "1", 1}.Uniq(nil)}.JSONString(), `[1,"1"]`)
+	assert.Equal(t, Value{value: Array{Array{1, 1.0}}.Uniq(nil, 0)}.JSONString(), `[1]`)
+
+	assert.Equal(t, Value{value: val.Chunk(3, "users")}.Len(), 2)
+	assert.Equal(t, Value{value: Array{1, 2, 3, 4, 5}.Chunk(2)}.JSONString(), `[[1,2],[3,4],[5]]`)
+	assert.Equal(t, Array{1}.Chunk(0) == nil, true)
+
+	assert.Equal(t, Value{value: Array{1, 2}.Zip(Array{"a"}, Array{true, false, true})}.JSONString(), `[[1,"a",true],[2,null,false],[null,null,true]]`)
+
+	nested, _ := Unmarshal([]byte(`[1, [2, [3, [4]]], []]`))
+	assert.Equal(t, Value{value: nested.FlattenArray(1)}.JSONString(), `[1,2,[3,[4]]]`)
+	assert.Equal(t, Value{value: nested.FlattenArray(-1)}.JSONString(), `[1,2,3,4]`)
+	assert.Equal(t, Value{value: nested.Array().FlattenArray(0)}.JSONString(), nested.JSONString())
+}
@@ -0,0 +1,195 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMaxBytes        = errors.New("document too large")
+	ErrMaxDepth        = errors.New("nesting too deep")
+	ErrMaxStringLength = errors.New("string too long")
+	ErrMaxArrayLength  = errors.New("array too long")
+	ErrMaxObjectLength = errors.New("object has too many members")
+	ErrDuplicateKey    = errors.New("duplicate key")
+	ErrTrailingData    = errors.New("trailing data after top-level value")
+)
+
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysLast keeps the last of duplicate keys, like encoding/json.
+	DuplicateKeysLast DuplicateKeyPolicy = iota
+	// DuplicateKeysFirst keeps the first of duplicate keys.
+	DuplicateKeysFirst
+	// DuplicateKeysReject fails with ErrDuplicateKey.
+	DuplicateKeysReject
+)
+
+// UnmarshalOptions limits the documents accepted by UnmarshalWithOptions.
+// Zero limits are unlimited.
+type UnmarshalOptions struct {
+	MaxBytes        int
+	MaxDepth        int
+	MaxStringLength int
+	MaxArrayLength  int
+	MaxObjectLength int
+
+	DuplicateKeys DuplicateKeyPolicy
+	// OnDuplicateKey, if set, is called for every duplicate key whatever the
+	// policy.
+	OnDuplicateKey func(path string, offset int64)
+
+	// AllowTrailingData ignores anything after the first value instead of
+	// failing with ErrTrailingData.
+	AllowTrailingData bool
+}
+
+// UnmarshalError reports the byte offset and path at which a document
+// violated UnmarshalOptions.
+type UnmarshalError struct {
+	Offset int64
+	Path   string
+	Err    error
+}
+
+func (e *UnmarshalError) Error() string {
+	return fmt.Sprintf("jsons: unmarshal %s at offset %d: %v", e.Path, e.Offset, e.Err)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+func UnmarshalWithOptions(data []byte, opts UnmarshalOptions) (val Value, err error) {
+	if opts.MaxBytes > 0 && len(data) > opts.MaxBytes {
+		return val, &UnmarshalError{Offset: int64(opts.MaxBytes), Path: "$", Err: ErrMaxBytes}
+	}
+	var p = parser{data: data, opts: opts}
+	p.dec = json.NewDecoder(bytes.NewReader(data))
+	p.dec.UseNumber()
+	tok, err := p.token()
+	if err != nil {
+		return
+	}
+	if val.value, err = p.parse(tok); err != nil {
+		return
+	}
+	if !opts.AllowTrailingData {
+		if err = checkTrailing(data, p.dec.InputOffset()); err != nil {
+			val.value = nil
+		}
+	}
+	return
+}
+
+// parser builds a Value tree from decoder tokens, checking the options.
+type parser struct {
+	data  []byte
+	opts  UnmarshalOptions
+	dec   *json.Decoder
+	path  []interface{}
+	start int64
+}
+
+// token reads the next token, recording where it starts.
+func (p *parser) token() (json.Token, error) {
+	p.skip()
+	return p.dec.Token()
+}
+
+// skip records the start of the next token.
+func (p *parser) skip() {
+	p.start = p.dec.InputOffset()
+	for p.start < int64(len(p.data)) && bytes.IndexByte([]byte(" \t\r\n,:"), p.data[p.start]) >= 0 {
+		p.start++
+	}
+}
+
+func (p *parser) error(err error) error {
+	return &UnmarshalError{Offset: p.start, Path: JSONPath(p.path...), Err: err}
+}
+
+func (p *parser) parse(tok json.Token) (interface{}, error) {
+	switch v := tok.(type) {
+	case string:
+		if p.opts.MaxStringLength > 0 && len(v) > p.opts.MaxStringLength {
+			return nil, p.error(ErrMaxStringLength)
+		}
+		return v, nil
+	case json.Delim:
+		if p.opts.MaxDepth > 0 && len(p.path) >= p.opts.MaxDepth {
+			return nil, p.error(ErrMaxDepth)
+		}
+		if v == '[' {
+			return p.array()
+		}
+		return p.object()
+	}
+	return tok, nil
+}
+
+func (p *parser) array() (interface{}, error) {
+	var arr = make([]interface{}, 0)
+	for p.dec.More() {
+		if p.opts.MaxArrayLength > 0 && len(arr) >= p.opts.MaxArrayLength {
+			p.skip()
+			return nil, p.error(ErrMaxArrayLength)
+		}
+		p.path = append(p.path, len(arr))
+		tok, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		elem, err := p.parse(tok)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, elem)
+		p.path = p.path[:len(p.path)-1]
+	}
+	_, err := p.token()
+	return arr, err
+}
+
+func (p *parser) object() (interface{}, error) {
+	var obj = make(map[string]interface{})
+	for p.dec.More() {
+		tok, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		p.path = append(p.path, key)
+		if p.opts.MaxObjectLength > 0 && len(obj) >= p.opts.MaxObjectLength {
+			return nil, p.error(ErrMaxObjectLength)
+		}
+		if p.opts.MaxStringLength > 0 && len(key) > p.opts.MaxStringLength {
+			return nil, p.error(ErrMaxStringLength)
+		}
+		_, duplicate := obj[key]
+		if duplicate {
+			if p.opts.OnDuplicateKey != nil {
+				p.opts.OnDuplicateKey(JSONPath(p.path...), p.start)
+			}
+			if p.opts.DuplicateKeys == DuplicateKeysReject {
+				return nil, p.error(fmt.Errorf("%w %q", ErrDuplicateKey, key))
+			}
+		}
+		if tok, err = p.token(); err != nil {
+			return nil, err
+		}
+		elem, err := p.parse(tok)
+		if err != nil {
+			return nil, err
+		}
+		if !duplicate || p.opts.DuplicateKeys == DuplicateKeysLast {
+			obj[key] = elem
+		}
+		p.path = p.path[:len(p.path)-1]
+	}
+	_, err := p.token()
+	return obj, err
+}
@@ -0,0 +1,94 @@
+package jsons
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	var data = []byte(`{"a": [1, 2, {"b": "hello"}], "c": 1.50, "c": 2}`)
+
+	val, err := UnmarshalWithOptions(data, UnmarshalOptions{})
+	assert.NoError(t, err)
+	expected, _ := Unmarshal(data)
+	assert.Equal(t, val, expected)
+
+	val, err = UnmarshalWithOptions(data, UnmarshalOptions{DuplicateKeys: DuplicateKeysFirst})
+	assert.NoError(t, err)
+	assert.Equal(t, val.Number("c"), Number("1.50"))
+
+	var reported []string
+	_, err = UnmarshalWithOptions(data, UnmarshalOptions{
+		DuplicateKeys: DuplicateKeysReject,
+		OnDuplicateKey: func(path string, offset int64) {
+			reported = append(reported, path)
+		},
+	})
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.Equal(t, reported, []string{"$.c"})
+	assert.EqualError(t, err, `jsons: unmarshal $.c at offset 41: duplicate key "c"`)
+
+	var unmarshalErr *UnmarshalError
+	for _, c := range []struct {
+		opts     UnmarshalOptions
+		expected UnmarshalError
+	}{
+		{UnmarshalOptions{MaxBytes: 10}, UnmarshalError{Offset: 10, Path: "$", Err: ErrMaxBytes}},
+		{UnmarshalOptions{MaxDepth: 2}, UnmarshalError{Offset: 13, Path: "$.a[2]", Err: ErrMaxDepth}},
+		{UnmarshalOptions{MaxStringLength: 4}, UnmarshalError{Offset: 19, Path: "$.a[2].b", Err: ErrMaxStringLength}},
+		{UnmarshalOptions{MaxArrayLength: 2}, UnmarshalError{Offset: 13, Path: "$.a", Err: ErrMaxArrayLength}},
+		{UnmarshalOptions{MaxObjectLength: 1}, UnmarshalError{Offset: 30, Path: "$.c", Err: ErrMaxObjectLength}},
+		{UnmarshalOptions{MaxStringLength: 10, MaxDepth: 3, MaxArrayLength: 3}, UnmarshalError{}},
+	} {
+		_, err = UnmarshalWithOptions(data, c.opts)
+		if c.expected.Err == nil {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.True(t, errors.As(err, &unmarshalErr), c.opts)
+		assert.Equal(t, *unmarshalErr, c.expected)
+	}
+
+	_, err = UnmarshalWithOptions([]byte(`{"a": }`), UnmarshalOptions{})
+	assert.Error(t, err)
+}
+
+func TestUnmarshal_TrailingData(t *testing.T) {
+	var data = []byte(`{"a":1} garbage`)
+
+	_, err := Unmarshal(data)
+	assert.True(t, errors.Is(err, ErrTrailingData))
+	assert.EqualError(t, err, "jsons: unmarshal $ at offset 8: trailing data after top-level value")
+
+	var val Value
+	assert.True(t, errors.Is(val.UnmarshalJSON(data), ErrTrailingData))
+	assert.True(t, errors.Is(val.Scan([]byte(`1 2`)), ErrTrailingData))
+
+	_, err = UnmarshalWithOptions(data, UnmarshalOptions{})
+	assert.True(t, errors.Is(err, ErrTrailingData))
+	val, err = UnmarshalWithOptions(data, UnmarshalOptions{AllowTrailingData: true})
+	assert.NoError(t, err)
+	assert.Equal(t, val.Int("a"), int64(1))
+
+	val, err = Unmarshal([]byte(" {\"a\":1}\n\t "))
+	assert.NoError(t, err)
+	assert.Equal(t, val.Int("a"), int64(1))
+
+	vals, err := UnmarshalAll([]byte(`{"a":1} [2] "3" 4.0 null`))
+	assert.NoError(t, err)
+	assert.Equal(t, len(vals), 5)
+	assert.Equal(t, vals[0].Int("a"), int64(1))
+	assert.Equal(t, vals[1].Int(0), int64(2))
+	assert.Equal(t, vals[2].String(), "3")
+	assert.Equal(t, vals[3].Number(), Number("4.0"))
+	assert.True(t, vals[4].IsNull())
+
+	vals, err = UnmarshalAll(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(vals), 0)
+
+	_, err = UnmarshalAll([]byte(`{"a":1} garbage`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,319 @@
+package jsons
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// URLOptions configures FromURLValuesWithOptions and
+// Value.URLValuesWithOptions.
+//
+// Keys use bracket notation: "a[b][0]=1" sets a.b[0], and "tags[]=x&tags[]=y"
+// appends to tags. A "[]" followed by more keys sends the n-th value to the
+// n-th element, so "items[][id]=1&items[][id]=2" gives two items. A key
+// given more than once without "[]" becomes an Array too.
+//
+// A key may end with a type hint, ":string", ":number", ":bool", ":null" or
+// ":json", as in "age:number=30" or "tags[]:json=[]".
+type URLOptions struct {
+	// InferTypes reads unhinted "true", "false" and JSON numbers as Bool
+	// and Number instead of String.
+	InferTypes bool
+	// OmitHints writes values without type hints, the way a browser
+	// submits a form. Non-String leaves then read back as Strings, and
+	// null, empty Objects and empty Arrays are left out.
+	OmitHints bool
+}
+
+var urlHints = []string{"string", "number", "bool", "null", "json"}
+
+// urlAppend is the key of a "[]" segment.
+type urlAppend struct{}
+
+// FromURLValues converts a query string or form into an Object.
+func FromURLValues(vals url.Values) (Value, error) {
+	return FromURLValuesWithOptions(vals, URLOptions{})
+}
+
+// FromURLValuesWithOptions converts a query string or form into an Object.
+func FromURLValuesWithOptions(vals url.Values, opts URLOptions) (Value, error) {
+	var names = make([]string, 0, len(vals))
+	var limit = 1024
+	for name := range vals {
+		names = append(names, name)
+		limit += len(vals[name])
+	}
+	sort.Strings(names)
+
+	var root interface{} = map[string]interface{}{}
+	for _, name := range names {
+		key, hint := splitURLHint(name)
+		segs, err := parseURLKey(key)
+		if err != nil {
+			return Value{}, err
+		}
+		var list []interface{}
+		for _, s := range vals[name] {
+			val, err := opts.parse(s, hint)
+			if err != nil {
+				return Value{}, fmt.Errorf("jsons: url: %s: %v", name, err)
+			}
+			list = append(list, val)
+		}
+
+		var final = segs[len(segs)-1] == urlAppend{}
+		if final {
+			segs = segs[:len(segs)-1]
+		}
+		var inner bool
+		for _, seg := range segs {
+			if seg == (urlAppend{}) {
+				inner = true
+			}
+		}
+
+		if !inner {
+			var leaf interface{} = list
+			if !final && len(list) == 1 {
+				leaf = list[0]
+			}
+			if root, err = setPath(root, segs, leaf, limit); err != nil {
+				return Value{}, fmt.Errorf("jsons: url: %s %v", name, err)
+			}
+			continue
+		}
+		for i, val := range list {
+			var keys = make([]interface{}, len(segs))
+			for j, seg := range segs {
+				if seg == (urlAppend{}) {
+					seg = i
+				}
+				keys[j] = seg
+			}
+			if final {
+				val = []interface{}{val}
+			}
+			if root, err = setPath(root, keys, val, limit); err != nil {
+				return Value{}, fmt.Errorf("jsons: url: %s %v", name, err)
+			}
+		}
+	}
+	return Value{value: root}, nil
+}
+
+func splitURLHint(name string) (key, hint string) {
+	if i := strings.LastIndexByte(name, ':'); i >= 0 && strings.LastIndexByte(name, ']') < i {
+		for _, h := range urlHints {
+			if name[i+1:] == h {
+				return name[:i], h
+			}
+		}
+	}
+	return name, ""
+}
+
+// parseURLKey splits a bracket notation key into strings for object keys,
+// ints for array indexes and urlAppend for "[]".
+func parseURLKey(key string) ([]interface{}, error) {
+	var i = strings.IndexByte(key, '[')
+	if i < 0 {
+		return []interface{}{key}, nil
+	}
+	if i == 0 {
+		return nil, fmt.Errorf("jsons: url: key %q has no name", key)
+	}
+	var segs = []interface{}{key[:i]}
+	for rest := key[i:]; rest != ""; {
+		var end = strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return nil, fmt.Errorf("jsons: url: key %q has invalid brackets", key)
+		}
+		var seg = rest[1:end]
+		switch n, err := strconv.Atoi(seg); {
+		case seg == "":
+			segs = append(segs, urlAppend{})
+		case err == nil && n >= 0 && seg[0] != '+':
+			segs = append(segs, n)
+		default:
+			segs = append(segs, seg)
+		}
+		rest = rest[end+1:]
+	}
+	return segs, nil
+}
+
+func (o URLOptions) parse(s, hint string) (interface{}, error) {
+	switch hint {
+	case "string":
+		return s, nil
+	case "number":
+		if !json.Valid([]byte(s)) || s == "" || strings.Trim(s, "-+.0123456789eE") != "" {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		return json.Number(s), nil
+	case "bool":
+		switch strings.ToLower(s) {
+		case "on":
+			return true, nil
+		case "off", "":
+			return false, nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool %q", s)
+		}
+		return b, nil
+	case "null":
+		return nil, nil
+	case "json":
+		val, err := Unmarshal([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		return val.value, nil
+	}
+	if o.InferTypes {
+		switch {
+		case s == "true":
+			return true, nil
+		case s == "false":
+			return false, nil
+		case s != "" && json.Valid([]byte(s)) && strings.Trim(s, "-+.0123456789eE") == "":
+			return json.Number(s), nil
+		}
+	}
+	return s, nil
+}
+
+// URLValues encodes the Object at keys in bracket notation with type hints,
+// so that FromURLValues reads it back unchanged.
+func (v Value) URLValues(keys ...interface{}) (url.Values, error) {
+	return v.URLValuesWithOptions(URLOptions{}, keys...)
+}
+
+// URLValuesWithOptions encodes the Object at keys in bracket notation.
+// Arrays of Strings, or of scalars of one type, are written with "[]" and
+// other Arrays with indexes.
+func (v Value) URLValuesWithOptions(opts URLOptions, keys ...interface{}) (url.Values, error) {
+	src, err := normalize(v.Get(keys...).value)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := src.(Object)
+	if !ok {
+		return nil, fmt.Errorf("jsons: url: cannot encode %s, want object", typeName(src))
+	}
+	var vals = make(url.Values)
+	for _, key := range sortedKeys(obj) {
+		if key == "" || strings.ContainsAny(key, "[]") {
+			return nil, fmt.Errorf("jsons: url: invalid key %q", key)
+		}
+		if err = opts.encode(vals, key, obj[key]); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+func (o URLOptions) encode(vals url.Values, name string, src interface{}) error {
+	src, err := normalize(src)
+	if err != nil {
+		return err
+	}
+	switch v := src.(type) {
+	case Object:
+		if len(v) == 0 {
+			o.add(vals, name, "json", "{}")
+			return nil
+		}
+		for _, key := range sortedKeys(v) {
+			if strings.ContainsAny(key, "[]") {
+				return fmt.Errorf("jsons: url: invalid key %q", key)
+			}
+			if err = o.encode(vals, name+"["+key+"]", v[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Array:
+		if len(v) == 0 {
+			o.add(vals, name, "json", "[]")
+			return nil
+		}
+		if hint, ok := o.scalars(v); ok {
+			for _, elem := range v {
+				s, _ := urlScalar(elem)
+				o.add(vals, name+"[]", hint, s)
+			}
+			return nil
+		}
+		for i, elem := range v {
+			if err = o.encode(vals, name+"["+strconv.Itoa(i)+"]", elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, hint := urlScalar(src)
+	if hint == "null" {
+		s = ""
+	}
+	o.add(vals, name, hint, s)
+	return nil
+}
+
+// scalars reports whether arr holds only scalars that share a hint.
+func (o URLOptions) scalars(arr Array) (string, bool) {
+	var hint string
+	for i, elem := range arr {
+		elem, err := normalize(elem)
+		if err != nil {
+			return "", false
+		}
+		switch elem.(type) {
+		case Object, Array, nil:
+			return "", false
+		}
+		_, h := urlScalar(elem)
+		if i > 0 && h != hint {
+			return "", false
+		}
+		hint = h
+	}
+	return hint, true
+}
+
+func urlScalar(src interface{}) (s, hint string) {
+	src, _ = normalize(src)
+	switch v := src.(type) {
+	case Bool:
+		return strconv.FormatBool(bool(v)), "bool"
+	case Number:
+		return string(v), "number"
+	case String:
+		return string(v), ""
+	}
+	return "", "null"
+}
+
+func (o URLOptions) add(vals url.Values, name, hint, s string) {
+	if o.OmitHints {
+		if hint == "null" || hint == "json" {
+			return
+		}
+		vals.Add(name, s)
+		return
+	}
+	if hint == "" {
+		if _, h := splitURLHint(name); h == "" {
+			vals.Add(name, s)
+			return
+		}
+		hint = "string"
+	}
+	vals.Add(name+":"+hint, s)
+}
@@ -0,0 +1,73 @@
+package jsons
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestFromURLValues(t *testing.T) {
+	vals, _ := url.ParseQuery("name=bob&age:number=30&admin:bool=on&note:null=&a[b][0]=1&a[b][2]=3&tags[]=x&tags[]=y" +
+		"&items[][id]:number=1&items[][id]:number=2&items[][name]=p&items[][name]=q&ids=1&ids=2&meta:json={\"k\":[1]}")
+	val, err := FromURLValues(vals)
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"a":{"b":["1",null,"3"]},"admin":true,"age":30,"ids":["1","2"],"items":[{"id":1,"name":"p"},{"id":2,"name":"q"}],"meta":{"k":[1]},"name":"bob","note":null,"tags":["x","y"]}`)
+
+	vals, _ = url.ParseQuery("n=1.5&b=true&s:string=2&t=x")
+	val, err = FromURLValuesWithOptions(vals, URLOptions{InferTypes: true})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `{"b":true,"n":1.5,"s":"2","t":"x"}`)
+
+	for _, query := range []string{
+		"a=1&a[b]=2",
+		"[a]=1",
+		"a[b=1",
+		"a[b]c=1",
+		"a:number=x",
+		"a:bool=maybe",
+		"a:json={",
+		"a[99999]=1",
+	} {
+		vals, _ = url.ParseQuery(query)
+		_, err = FromURLValues(vals)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestValue_URLValues(t *testing.T) {
+	val, _ := Unmarshal([]byte(`{"name": "bob", "age": 30, "tags": ["x", "y"], "flags": [true, false], "mixed": [1, "a", null],
+		"items": [{"id": 1}], "none": null, "empty": {}, "list": [], "odd:number": "s", "a": {"b": {"c": "d"}}}`))
+	vals, err := val.URLValues()
+	assert.NoError(t, err)
+	assert.Equal(t, vals.Encode(), url.Values{
+		"name":                {"bob"},
+		"age:number":          {"30"},
+		"tags[]":              {"x", "y"},
+		"flags[]:bool":        {"true", "false"},
+		"mixed[0]:number":     {"1"},
+		"mixed[1]":            {"a"},
+		"mixed[2]:null":       {""},
+		"items[0][id]:number": {"1"},
+		"none:null":           {""},
+		"empty:json":          {"{}"},
+		"list:json":           {"[]"},
+		"odd:number:string":   {"s"},
+		"a[b][c]":             {"d"},
+	}.Encode())
+
+	back, err := FromURLValues(vals)
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), val.JSONString())
+
+	vals, err = val.URLValuesWithOptions(URLOptions{OmitHints: true}, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, vals.Encode(), "b%5Bc%5D=d")
+
+	_, err = Value{value: Array{1}}.URLValues()
+	assert.Error(t, err)
+	_, err = val.URLValues("tags")
+	assert.Error(t, err)
+	_, err = Value{value: Object{"a[": 1}}.URLValues()
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -38,6 +39,8 @@ func value(v interface{}) Value {
 		if v != nil {
 			val.value = Object(v)
 		}
+	case time.Time:
+		val.value = String(v.Format(TimeLayout))
 	case nil:
 		val.value = nil
 	default:
@@ -138,6 +141,9 @@ func (v *Value) UnmarshalJSON(data []byte) error {
 	if err = decoder.Decode(&val); err != nil {
 		return err
 	}
+	if err = checkTrailing(data, decoder.InputOffset()); err != nil {
+		return err
+	}
 	v.value = value(val)
 	return nil
 }
@@ -487,6 +493,19 @@ func (v Value) Marshal(obj interface{}) error {
 	return json.Unmarshal(data, obj)
 }
 
+func (v Value) Decode(target interface{}, keys ...interface{}) error {
+	return v.DecodeWithOptions(target, DecodeOptions{}, keys...)
+}
+
+func (v Value) DecodeWithOptions(target interface{}, opts DecodeOptions, keys ...interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("jsons: decode target must be a non-nil pointer, got %T", target)
+	}
+	var d = decoder{opts: opts}
+	return d.decodeAt(v, keys, rv.Elem())
+}
+
 func (v *Value) Unmarshal(obj interface{}) error {
 	data, err := json.Marshal(obj)
 	if err != nil {
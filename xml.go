@@ -0,0 +1,420 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// XMLStyle selects the convention used to map XML to a Value and back.
+type XMLStyle int
+
+const (
+	// XMLDefault maps attributes to "@name" keys and text to "#text"; an
+	// element with neither attributes nor children becomes its text.
+	XMLDefault XMLStyle = iota
+	// XMLBadgerFish maps every element to an Object, with text under "$".
+	XMLBadgerFish
+	// XMLParker drops attributes and maps elements to their text or
+	// children; an element whose children all share one name becomes an
+	// Array, and the root element is not named.
+	XMLParker
+)
+
+// XMLOptions configures FromXMLWithOptions and Value.XMLWithOptions. In every
+// style repeated child elements become an Array, in document order; the
+// order of differently named children and of text between them is lost.
+type XMLOptions struct {
+	Style XMLStyle
+	// AttrPrefix prefixes attribute keys, "@" by default.
+	AttrPrefix string
+	// TextKey holds the text of elements that also have attributes or
+	// children, "#text" by default and "$" for XMLBadgerFish.
+	TextKey string
+	// ForceArray lists element names decoded as an Array even when they
+	// occur once.
+	ForceArray []string
+	// InferTypes decodes "true", "false" and JSON numbers in text and
+	// attributes as Bool and Number instead of String.
+	InferTypes bool
+	// Indent, if set, indents nested elements when encoding.
+	Indent string
+}
+
+func (o XMLOptions) attrPrefix() string {
+	if o.AttrPrefix == "" {
+		return "@"
+	}
+	return o.AttrPrefix
+}
+
+func (o XMLOptions) textKey() string {
+	switch {
+	case o.TextKey != "":
+		return o.TextKey
+	case o.Style == XMLBadgerFish:
+		return "$"
+	}
+	return "#text"
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// FromXML converts an XML document to a Value in the XMLDefault style.
+func FromXML(data []byte) (Value, error) {
+	return FromXMLWithOptions(data, XMLOptions{})
+}
+
+// FromXMLWithOptions converts an XML document to a Value. Names keep their
+// namespace prefix, as in "soap:Envelope", and namespace declarations are
+// kept as attributes.
+func FromXMLWithOptions(data []byte, opts XMLOptions) (Value, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return Value{}, err
+	}
+	var conv = xmlConverter{opts: opts, force: make(map[string]bool)}
+	for _, name := range opts.ForceArray {
+		conv.force[name] = true
+	}
+	if opts.Style == XMLParker {
+		return Value{value: conv.element(root)}, nil
+	}
+	return Value{value: map[string]interface{}{root.name: conv.element(root)}}, nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+func parseXML(data []byte) (*xmlNode, error) {
+	var dec = xml.NewDecoder(bytes.NewReader(data))
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jsons: xml: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			var node = &xmlNode{name: xmlName(tok.Name), attrs: tok.Attr}
+			for i, attr := range tok.Attr {
+				for _, prev := range tok.Attr[:i] {
+					if prev.Name == attr.Name {
+						return nil, fmt.Errorf("jsons: xml: duplicate attribute %s on <%s>", xmlName(attr.Name), node.name)
+					}
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root != nil {
+				return nil, errors.New("jsons: xml: more than one root element")
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != xmlName(tok.Name) {
+				return nil, fmt.Errorf("jsons: xml: unexpected </%s> at offset %d", xmlName(tok.Name), dec.InputOffset())
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			} else if len(bytes.TrimSpace(tok)) > 0 {
+				return nil, errors.New("jsons: xml: text outside the root element")
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("jsons: xml: no root element")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("jsons: xml: element <%s> is not closed", stack[len(stack)-1].name)
+	}
+	return root, nil
+}
+
+type xmlConverter struct {
+	opts  XMLOptions
+	force map[string]bool
+}
+
+func (c xmlConverter) scalar(s string) interface{} {
+	if c.opts.InferTypes {
+		switch {
+		case s == "true":
+			return true
+		case s == "false":
+			return false
+		case s != "" && json.Valid([]byte(s)) && strings.Trim(s, "-+.0123456789eE") == "":
+			return json.Number(s)
+		}
+	}
+	return s
+}
+
+func (c xmlConverter) element(n *xmlNode) interface{} {
+	var text = n.text.String()
+	if len(n.children) > 0 {
+		text = strings.TrimSpace(text)
+	}
+	var attrs = n.attrs
+	if c.opts.Style == XMLParker {
+		attrs = nil
+	}
+
+	if len(attrs) == 0 && len(n.children) == 0 {
+		switch {
+		case c.opts.Style == XMLBadgerFish && text == "":
+			return map[string]interface{}{}
+		case c.opts.Style == XMLBadgerFish:
+			return map[string]interface{}{c.opts.textKey(): c.scalar(text)}
+		case c.opts.Style == XMLParker && text == "":
+			return nil
+		}
+		return c.scalar(text)
+	}
+
+	if c.opts.Style == XMLParker && len(n.children) > 1 {
+		var arr = make([]interface{}, 0, len(n.children))
+		for _, child := range n.children {
+			if child.name != n.children[0].name {
+				arr = nil
+				break
+			}
+			arr = append(arr, c.element(child))
+		}
+		if arr != nil {
+			return arr
+		}
+	}
+
+	var obj = make(map[string]interface{})
+	for _, attr := range attrs {
+		obj[c.opts.attrPrefix()+xmlName(attr.Name)] = c.scalar(attr.Value)
+	}
+	var arrays = make(map[string]bool)
+	for _, child := range n.children {
+		var val = c.element(child)
+		prev, exists := obj[child.name]
+		switch {
+		case arrays[child.name]:
+			obj[child.name] = append(prev.([]interface{}), val)
+		case exists:
+			obj[child.name] = []interface{}{prev, val}
+			arrays[child.name] = true
+		case c.force[child.name]:
+			obj[child.name] = []interface{}{val}
+			arrays[child.name] = true
+		default:
+			obj[child.name] = val
+		}
+	}
+	if text != "" {
+		obj[c.opts.textKey()] = c.scalar(text)
+	}
+	return obj
+}
+
+// XML encodes the value at keys as an XML element named root, using the
+// XMLDefault style.
+func (v Value) XML(root string, keys ...interface{}) ([]byte, error) {
+	return v.XMLWithOptions(root, XMLOptions{}, keys...)
+}
+
+// XMLWithOptions encodes the value at keys as an XML element named root. An
+// empty root names the element after the only key of an Object, the shape
+// FromXML produces. Arrays become repeated elements; an Array nested in
+// another becomes an element of "item" children.
+func (v Value) XMLWithOptions(root string, opts XMLOptions, keys ...interface{}) ([]byte, error) {
+	src, err := normalize(v.Get(keys...).value)
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		obj, ok := src.(Object)
+		if !ok || len(obj) != 1 {
+			return nil, errors.New("jsons: xml: root name required unless the value is an object with one key")
+		}
+		for root, src = range obj {
+		}
+		if src, err = normalize(src); err != nil {
+			return nil, err
+		}
+	}
+	if arr, ok := src.(Array); ok {
+		src = Object{"item": arr}
+	}
+	var enc = xmlEncoder{opts: opts}
+	if err = enc.element(root, src, 0); err != nil {
+		return nil, err
+	}
+	return enc.Bytes(), nil
+}
+
+type xmlEncoder struct {
+	bytes.Buffer
+	opts XMLOptions
+}
+
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_' || r == ':':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (e *xmlEncoder) indent(depth int) {
+	if e.opts.Indent != "" && e.Len() > 0 {
+		e.WriteByte('\n')
+		e.WriteString(strings.Repeat(e.opts.Indent, depth))
+	}
+}
+
+func (e *xmlEncoder) text(src interface{}) (string, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", nil
+	case Bool:
+		if v {
+			return "true", nil
+		}
+		return "false", nil
+	case Number:
+		return string(v), nil
+	case String:
+		return string(v), nil
+	}
+	return "", fmt.Errorf("jsons: xml: cannot write %s as text", typeName(src))
+}
+
+func (e *xmlEncoder) element(name string, src interface{}, depth int) error {
+	if !isXMLName(name) {
+		return fmt.Errorf("jsons: xml: invalid element name %q", name)
+	}
+	src, err := normalize(src)
+	if err != nil {
+		return err
+	}
+
+	switch v := src.(type) {
+	case Array:
+		for _, elem := range v {
+			elem, err := normalize(elem)
+			if err != nil {
+				return err
+			}
+			if arr, ok := elem.(Array); ok {
+				elem = Object{"item": arr}
+			}
+			if err = e.element(name, elem, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Object:
+		return e.object(name, v, depth)
+	}
+
+	text, err := e.text(src)
+	if err != nil {
+		return err
+	}
+	e.indent(depth)
+	if src == nil {
+		fmt.Fprintf(e, "<%s/>", name)
+		return nil
+	}
+	fmt.Fprintf(e, "<%s>", name)
+	if err = xml.EscapeText(e, []byte(text)); err != nil {
+		return err
+	}
+	fmt.Fprintf(e, "</%s>", name)
+	return nil
+}
+
+func (e *xmlEncoder) object(name string, obj Object, depth int) error {
+	var prefix, textKey = e.opts.attrPrefix(), e.opts.textKey()
+	var text string
+	var children []string
+
+	e.indent(depth)
+	e.WriteString("<" + name)
+	for _, key := range sortedKeys(obj) {
+		switch {
+		case key == textKey:
+			val, err := normalize(obj[key])
+			if err != nil {
+				return err
+			}
+			if text, err = e.text(val); err != nil {
+				return err
+			}
+		case strings.HasPrefix(key, prefix):
+			var attr = strings.TrimPrefix(key, prefix)
+			if !isXMLName(attr) {
+				return fmt.Errorf("jsons: xml: invalid attribute name %q", attr)
+			}
+			val, err := normalize(obj[key])
+			if err != nil {
+				return err
+			}
+			str, err := e.text(val)
+			if err != nil {
+				return err
+			}
+			e.WriteString(" " + attr + `="`)
+			if err = xml.EscapeText(e, []byte(str)); err != nil {
+				return err
+			}
+			e.WriteByte('"')
+		default:
+			children = append(children, key)
+		}
+	}
+	if text == "" && len(children) == 0 {
+		e.WriteString("/>")
+		return nil
+	}
+	e.WriteByte('>')
+	if err := xml.EscapeText(e, []byte(text)); err != nil {
+		return err
+	}
+	for _, key := range children {
+		if err := e.element(key, obj[key], depth+1); err != nil {
+			return err
+		}
+	}
+	if len(children) > 0 {
+		e.indent(depth)
+	}
+	e.WriteString("</" + name + ">")
+	return nil
+}
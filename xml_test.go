@@ -0,0 +1,88 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+const testSOAP = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <order id="42" rush="true">
+      <item sku="a1">2</item>
+      <item sku="b2">1</item>
+      <note>leave at door &amp; ring</note>
+      <gift/>
+    </order>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestFromXML(t *testing.T) {
+	val, err := FromXML([]byte(testSOAP))
+	assert.NoError(t, err)
+	var order = val.Get("soap:Envelope", "soap:Body", "order")
+	assert.Equal(t, val.String("soap:Envelope", "@xmlns:soap"), "http://www.w3.org/2003/05/soap-envelope")
+	assert.Equal(t, order.String("@id"), "42")
+	assert.Equal(t, order.Len("item"), 2)
+	assert.Equal(t, order.String("item", 1, "@sku"), "b2")
+	assert.Equal(t, order.String("item", 0, "#text"), "2")
+	assert.Equal(t, order.String("note"), "leave at door & ring")
+	assert.Equal(t, order.String("gift"), "")
+
+	val, err = FromXMLWithOptions([]byte(testSOAP), XMLOptions{InferTypes: true, ForceArray: []string{"note"}})
+	assert.NoError(t, err)
+	order = val.Get("soap:Envelope", "soap:Body", "order")
+	assert.Equal(t, order.Number("@id"), Number("42"))
+	assert.Equal(t, order.Bool("@rush"), true)
+	assert.Equal(t, order.String("note", 0), "leave at door & ring")
+
+	val, err = FromXMLWithOptions([]byte(testSOAP), XMLOptions{Style: XMLBadgerFish})
+	assert.NoError(t, err)
+	order = val.Get("soap:Envelope", "soap:Body", "order")
+	assert.Equal(t, order.String("item", 0, "$"), "2")
+	assert.Equal(t, order.String("note", "$"), "leave at door & ring")
+	assert.True(t, order.IsObject("gift"))
+
+	val, err = FromXMLWithOptions([]byte(`<list><n>1</n><n>2</n><n/></list>`), XMLOptions{Style: XMLParker, InferTypes: true})
+	assert.NoError(t, err)
+	assert.Equal(t, val.JSONString(), `[1,2,null]`)
+
+	for _, data := range []string{``, `<a>`, `<a></b>`, `<a/><b/>`, `<a/>text`, `<a x="1" x="2"/>`} {
+		_, err = FromXML([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestValue_XML(t *testing.T) {
+	val, err := FromXML([]byte(testSOAP))
+	assert.NoError(t, err)
+	data, err := val.XML("")
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><order id="42" rush="true"><gift></gift><item sku="a1">2</item><item sku="b2">1</item><note>leave at door &amp; ring</note></order></soap:Body></soap:Envelope>`)
+
+	back, err := FromXML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), val.JSONString())
+
+	val, _ = Unmarshal([]byte(`{"user": {"@id": 7, "name": "a<b", "tags": ["x", "y"], "extra": null}}`))
+	data, err = val.XMLWithOptions("", XMLOptions{Indent: "  "})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `<user id="7">
+  <extra/>
+  <name>a&lt;b</name>
+  <tags>x</tags>
+  <tags>y</tags>
+</user>`)
+
+	data, err = Value{value: Array{1, Array{2, 3}}}.XMLWithOptions("list", XMLOptions{Style: XMLParker})
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `<list><item>1</item><item><item>2</item><item>3</item></item></list>`)
+
+	_, err = val.XML("1bad")
+	assert.Error(t, err)
+	_, err = Value{value: Object{"a": 1, "b": 2}}.XML("")
+	assert.Error(t, err)
+	_, err = Value{value: Object{"@a": Array{1}}}.XML("r")
+	assert.Error(t, err)
+}
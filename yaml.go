@@ -0,0 +1,299 @@
+package jsons
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxYAMLNodes bounds alias expansion against "billion laughs" documents.
+const maxYAMLNodes = 10000000
+
+// FromYAML converts a YAML document to a Value: mappings become Objects,
+// sequences Arrays and scalars Strings, Numbers, Bools or null. Aliases are
+// expanded and merge keys applied. Mapping keys must be strings. A stream of
+// several "---" separated documents is an error; use FromYAMLAll for it.
+func FromYAML(data []byte) (Value, error) {
+	docs, err := FromYAMLAll(data)
+	if err != nil {
+		return Value{}, err
+	}
+	switch len(docs) {
+	case 0:
+		return Value{}, nil
+	case 1:
+		return Value{value: docs[0]}, nil
+	}
+	return Value{}, fmt.Errorf("jsons: yaml: stream holds %d documents, use FromYAMLAll", len(docs))
+}
+
+// FromYAMLAll converts every document of a YAML stream as FromYAML does,
+// returning one element per document.
+func FromYAMLAll(data []byte) (Array, error) {
+	var dec = yaml.NewDecoder(bytes.NewReader(data))
+	var c yamlConverter
+	var docs = make(Array, 0)
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if len(doc.Content) > 0 {
+			var err error
+			if v, err = c.convert(doc.Content[0]); err != nil {
+				return nil, err
+			}
+		}
+		docs = append(docs, v)
+	}
+}
+
+type yamlConverter struct {
+	nodes int
+}
+
+func (c *yamlConverter) convert(n *yaml.Node) (interface{}, error) {
+	if c.nodes++; c.nodes > maxYAMLNodes {
+		return nil, errors.New("jsons: yaml: document expands to too many nodes")
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return c.convert(n.Content[0])
+	case yaml.AliasNode:
+		return c.convert(n.Alias)
+	case yaml.SequenceNode:
+		var arr = make([]interface{}, len(n.Content))
+		for i, elem := range n.Content {
+			v, err := c.convert(elem)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return arr, nil
+	case yaml.MappingNode:
+		var obj = make(map[string]interface{})
+		if err := c.mapping(n, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case yaml.ScalarNode:
+		return c.scalar(n)
+	}
+	return nil, fmt.Errorf("jsons: yaml: line %d: unsupported node", n.Line)
+}
+
+// mapping fills obj with the pairs of n. Merge keys ("<<") only fill keys
+// that n does not set itself.
+func (c *yamlConverter) mapping(n *yaml.Node, obj map[string]interface{}) error {
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		for key.Kind == yaml.AliasNode {
+			key = key.Alias
+		}
+		if key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge" {
+			merges = append(merges, val)
+			continue
+		}
+		if key.Kind != yaml.ScalarNode || key.ShortTag() != "!!str" {
+			return fmt.Errorf("jsons: yaml: line %d: mapping key %q is not a string", key.Line, key.Value)
+		}
+		v, err := c.convert(val)
+		if err != nil {
+			return err
+		}
+		obj[key.Value] = v
+	}
+
+	for _, merge := range merges {
+		for merge.Kind == yaml.AliasNode {
+			merge = merge.Alias
+		}
+		var sources = []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+		for _, src := range sources {
+			for src.Kind == yaml.AliasNode {
+				src = src.Alias
+			}
+			if src.Kind != yaml.MappingNode {
+				return fmt.Errorf("jsons: yaml: line %d: merge value is not a mapping", src.Line)
+			}
+			var merged = make(map[string]interface{})
+			if err := c.mapping(src, merged); err != nil {
+				return err
+			}
+			for k, v := range merged {
+				if _, exists := obj[k]; !exists {
+					obj[k] = v
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *yamlConverter) scalar(n *yaml.Node) (interface{}, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "!!int":
+		return yamlInt(n)
+	case "!!float":
+		return yamlFloat(n)
+	}
+	return n.Value, nil
+}
+
+// yamlInt keeps decimal spellings and converts the others (0x1F, 0o17, 1_000).
+func yamlInt(n *yaml.Node) (interface{}, error) {
+	var s = strings.TrimPrefix(n.Value, "+")
+	if json.Valid([]byte(s)) && !strings.ContainsAny(s, ".eE") {
+		return json.Number(s), nil
+	}
+	var digits = strings.ReplaceAll(s, "_", "")
+	var neg bool
+	if strings.HasPrefix(digits, "-") {
+		neg, digits = true, digits[1:]
+	}
+	if strings.HasPrefix(digits, "0o") || strings.HasPrefix(digits, "0O") {
+		digits = "0" + digits[2:]
+	}
+	i, ok := new(big.Int).SetString(digits, 0)
+	if !ok {
+		return nil, fmt.Errorf("jsons: yaml: line %d: invalid integer %q", n.Line, n.Value)
+	}
+	if neg {
+		i.Neg(i)
+	}
+	return json.Number(i.String()), nil
+}
+
+// yamlFloat keeps decimal spellings and rejects .inf and .nan, which JSON
+// cannot hold.
+func yamlFloat(n *yaml.Node) (interface{}, error) {
+	var s = strings.TrimPrefix(n.Value, "+")
+	switch strings.ToLower(s) {
+	case ".inf", "-.inf", ".nan":
+		return nil, fmt.Errorf("jsons: yaml: line %d: %s is not a valid JSON number", n.Line, n.Value)
+	}
+	if json.Valid([]byte(s)) {
+		return json.Number(s), nil
+	}
+	var f float64
+	if err := n.Decode(&f); err != nil {
+		return nil, err
+	}
+	num := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(num, ".eE") {
+		num += ".0"
+	}
+	return json.Number(num), nil
+}
+
+// YAML encodes the value at keys as YAML with sorted keys, keeping the
+// spelling of Numbers.
+func (v Value) YAML(keys ...interface{}) ([]byte, error) {
+	n, err := yamlNode(v.Get(keys...).value)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(n); err != nil {
+		return nil, err
+	}
+	if err = enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func yamlNode(src interface{}) (*yaml.Node, error) {
+	src, err := normalize(src)
+	if err != nil {
+		return nil, err
+	}
+	switch v := src.(type) {
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	case Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(bool(v))}, nil
+	case Number:
+		if v == "" {
+			v = "0"
+		}
+		if !json.Valid([]byte(v)) {
+			return nil, fmt.Errorf("jsons: invalid number %q", string(v))
+		}
+		if strings.ContainsAny(string(v), ".eE") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: string(v)}, nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: string(v)}, nil
+	case String:
+		var n = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(v)}
+		if yaml11Bool(string(v)) {
+			n.Style = yaml.DoubleQuotedStyle
+		}
+		return n, nil
+	case Array:
+		var n = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, elem := range v {
+			child, err := yamlNode(elem)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, child)
+		}
+		return n, nil
+	case Object:
+		var keys = make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var n = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			child, err := yamlNode(v[key])
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("jsons: cannot encode %T as yaml", src)
+}
+
+// yaml11Bool reports whether s reads as a bool to YAML 1.1 parsers, which
+// many Kubernetes tools still use, so it has to be quoted.
+func yaml11Bool(s string) bool {
+	switch strings.ToLower(s) {
+	case "y", "n", "yes", "no", "on", "off":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package jsons
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestFromYAML(t *testing.T) {
+	val, err := FromYAML([]byte(`
+defaults: &defaults
+  replicas: 2
+  image: nginx
+app:
+  <<: *defaults
+  replicas: 3
+  ports: [80, 0x1BB]
+  ratio: 1.10
+  big: 123456789012345678901234567890
+  enabled: yes
+  tls: true
+  empty: ~
+  "quoted": "123"
+  when: 2022-05-01T10:20:30Z
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, val.Int("app", "replicas"), int64(3))
+	assert.Equal(t, val.String("app", "image"), "nginx")
+	assert.Equal(t, val.Number("app", "ports", 1), Number("443"))
+	assert.Equal(t, val.Number("app", "ratio"), Number("1.10"))
+	assert.Equal(t, val.Number("app", "big"), Number("123456789012345678901234567890"))
+	assert.Equal(t, val.String("app", "enabled"), "yes")
+	assert.Equal(t, val.Bool("app", "tls"), true)
+	assert.True(t, val.Exist("app", "empty"))
+	assert.True(t, val.IsNull("app", "empty"))
+	assert.Equal(t, val.String("app", "quoted"), "123")
+	assert.Equal(t, val.Time("app", "when").Unix(), int64(1651400430))
+
+	_, err = FromYAML([]byte("1: a\n"))
+	assert.Error(t, err)
+	_, err = FromYAML([]byte("a: [1\n"))
+	assert.Error(t, err)
+	for _, data := range []string{"a: .inf", "a: -.Inf", "a: [.nan]"} {
+		_, err = FromYAML([]byte(data))
+		assert.Error(t, err, data)
+	}
+	_, err = FromYAML([]byte("a: .inf"))
+	assert.EqualError(t, err, "jsons: yaml: line 1: .inf is not a valid JSON number")
+
+	val, err = FromYAML(nil)
+	assert.NoError(t, err)
+	assert.True(t, val.IsNull())
+
+	_, err = FromYAML([]byte("a: 1\n---\nb: 2\n"))
+	assert.EqualError(t, err, "jsons: yaml: stream holds 2 documents, use FromYAMLAll")
+}
+
+func TestFromYAMLAll(t *testing.T) {
+	docs, err := FromYAMLAll([]byte("a: 1\n---\nb: [x]\n---\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, len(docs), 3)
+	assert.Equal(t, docs.Get(0).Number("a"), Number("1"))
+	assert.Equal(t, docs.Get(1).String("b", 0), "x")
+	assert.True(t, docs.Get(2).IsNull())
+
+	docs, err = FromYAMLAll(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(docs), 0)
+
+	_, err = FromYAMLAll([]byte("a: 1\n---\n1: a\n"))
+	assert.Error(t, err)
+}
+
+func TestValue_YAML_NonFinite(t *testing.T) {
+	_, err := Value{value: Object{"a": Number("Infinity")}}.YAML()
+	assert.Error(t, err)
+}
+
+func TestValue_YAML(t *testing.T) {
+	val, err := Unmarshal([]byte(`{"b": [1, 2.50, "3", true, null], "a": {"s": "yes", "multi": "x\ny", "e": {}}}`))
+	assert.NoError(t, err)
+	data, err := val.YAML()
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), `a:
+  e: {}
+  multi: |-
+    x
+    y
+  s: "yes"
+b:
+  - 1
+  - 2.50
+  - "3"
+  - true
+  - null
+`)
+
+	back, err := FromYAML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, back.JSONString(), val.JSONString())
+
+	data, err = val.YAML("b", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "2.50\n")
+}